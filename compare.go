@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/tpriime/ec2diff/pkg"
+	"github.com/tpriime/ec2diff/pkg/delta"
+)
+
+// runCompare prints how drift changed between two saved reports: what is new,
+// resolved or changed since the older one. Unchanged drift is only printed with -all.
+func runCompare(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("ec2diff compare", flag.ContinueOnError)
+	fs.SetOutput(out)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: ec2diff compare [flags] old.json new.json")
+		fs.PrintDefaults()
+	}
+
+	output := fs.String("output", outputTable, "Report format: table, json or ndjson.")
+	all := fs.Bool("all", false, "Also print drift that is unchanged.")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("compare expects an old and a new report")
+	}
+
+	printer, err := newReportPrinter(*output, out)
+	if err != nil {
+		return err
+	}
+
+	older, err := delta.Load(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to load old report: %w", err)
+	}
+	newer, err := delta.Load(fs.Arg(1))
+	if err != nil {
+		return fmt.Errorf("failed to load new report: %w", err)
+	}
+
+	reports := delta.Compare(older, newer, *all)
+	pkg.PrintAll(printer, reports, delta.Summarize(reports))
+	return nil
+}
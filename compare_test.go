@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tpriime/ec2diff/pkg"
+	"github.com/tpriime/ec2diff/pkg/jsonprinter"
+)
+
+// saveReports writes reports as the JSON output would.
+func saveReports(t *testing.T, name string, reports []pkg.Report) string {
+	t.Helper()
+	var buf bytes.Buffer
+	pkg.PrintAll(jsonprinter.NewJSONPrinter(&buf), reports, pkg.Summarize(reports))
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunCompare(t *testing.T) {
+	typeDrift := []pkg.AttributeDrift{{Name: pkg.AttrInstanceType, Expected: "t3.large", Found: "t3.micro"}}
+	older := saveReports(t, "old.json", []pkg.Report{
+		{InstanceID: "i-old", Comment: pkg.CommentDriftDetected, Drifts: typeDrift},
+		{InstanceID: "i-same", Comment: pkg.CommentDriftDetected, Drifts: typeDrift},
+	})
+	newer := saveReports(t, "new.json", []pkg.Report{
+		{InstanceID: "i-new", Comment: pkg.CommentMissingState, Drifts: typeDrift},
+		{InstanceID: "i-same", Comment: pkg.CommentDriftDetected, Drifts: typeDrift},
+	})
+
+	var out bytes.Buffer
+	err := run(t.Context(), []string{"compare", "-output", "json", older, newer}, &out)
+
+	assert.NoError(t, err)
+	var doc struct{ Reports []pkg.Report }
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &doc))
+	assert.Len(t, doc.Reports, 2)
+	assert.Equal(t, "i-new", doc.Reports[0].InstanceID)
+	assert.Equal(t, pkg.ChangeNew, doc.Reports[0].Change)
+	assert.Equal(t, "i-old", doc.Reports[1].InstanceID)
+	assert.Equal(t, pkg.ChangeResolved, doc.Reports[1].Change)
+
+	out.Reset()
+	err = run(t.Context(), []string{"compare", "-all", older, newer}, &out)
+
+	assert.NoError(t, err)
+	assert.Regexp(t, `i-same\n.*\nChange +: unchanged`, out.String())
+}
+
+func TestRunCompare_SummarizesChanges(t *testing.T) {
+	typeDrift := []pkg.AttributeDrift{{Name: pkg.AttrInstanceType, Expected: "t3.large", Found: "t3.micro"}}
+	older := saveReports(t, "old.json", []pkg.Report{{InstanceID: "i-1", Comment: pkg.CommentDriftDetected, Drifts: typeDrift}})
+	newer := saveReports(t, "new.json", []pkg.Report{{InstanceID: "i-1", Comment: pkg.CommentNoDriftDetected, Drifts: []pkg.AttributeDrift{}}})
+
+	var out bytes.Buffer
+	err := run(t.Context(), []string{"compare", older, newer}, &out)
+
+	assert.NoError(t, err)
+	assert.Regexp(t, `Resolved +: 1\n`, out.String())
+	assert.NotContains(t, out.String(), "Drifted", "resolved drift should not be counted as drifted")
+}
+
+func TestRunCompare_Args(t *testing.T) {
+	err := run(t.Context(), []string{"compare", "old.json"}, &bytes.Buffer{})
+	assert.ErrorContains(t, err, "expects an old and a new report")
+
+	err = run(t.Context(), []string{"compare", "missing.json", "missing.json"}, &bytes.Buffer{})
+	assert.ErrorContains(t, err, "failed to load old report")
+}
+
+func TestRunCompare_NullValues(t *testing.T) {
+	dir := t.TempDir()
+	older := filepath.Join(dir, "old.json")
+	newer := filepath.Join(dir, "new.json")
+	report := `{"reports": [{"instance_id": "i-1", "comment": "Drifts detected", "drifts": [
+		{"name": "security_groups", "expected": null, "found": ["sg-1"]},
+		{"name": "iam_instance_profile", "expected": "arn:aws:iam::123456789012:instance-profile/web", "found": null}
+	]}]}`
+	assert.NoError(t, os.WriteFile(older, []byte(`{"reports": []}`), 0o644))
+	assert.NoError(t, os.WriteFile(newer, []byte(report), 0o644))
+
+	var out bytes.Buffer
+	err := run(t.Context(), []string{"compare", older, newer}, &out)
+
+	assert.NoError(t, err)
+	assert.Regexp(t, `security_groups +null +\["sg-1"\]`, out.String())
+	assert.Regexp(t, `iam_instance_profile +\S+ +null`, out.String())
+}
@@ -1,3 +1,8 @@
+import {
+  to = aws_instance.example
+  id = "i-0f3d4bc78c78cc67b"
+}
+
 resource "aws_instance" "example" {
   ami                    = "ami-0c94855ba95c71c99"
   instance_type          = "t2.micro"
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tpriime/ec2diff/pkg"
+	"github.com/tpriime/ec2diff/pkg/snapshot"
+)
+
+// runSummary describes a saved run, without its reports.
+type runSummary struct {
+	ID        string      `json:"id"`
+	StartedAt time.Time   `json:"started_at"`
+	Files     []string    `json:"files"`
+	StateHash string      `json:"state_hash"`
+	Partial   string      `json:"partial,omitempty"`
+	Summary   pkg.Summary `json:"summary"`
+}
+
+// historyDocument is the JSON output of the history subcommand.
+type historyDocument struct {
+	Runs      []runSummary               `json:"runs"`
+	Instances []snapshot.InstanceHistory `json:"instances"`
+}
+
+// runHistory lists the runs saved with -save and, per instance, when drift
+// first appeared and when it was resolved. Ongoing drift is aged up to now.
+func runHistory(args []string, out io.Writer, now time.Time) error {
+	fs := flag.NewFlagSet("ec2diff history", flag.ContinueOnError)
+	fs.SetOutput(out)
+
+	dir := fs.String("dir", "", "Snapshot directory given to -save.")
+	instanceID := fs.String("instance-id", "", "Only show the history of this instance.")
+	output := fs.String("output", outputTable, "Format: table or json.")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *dir == "" {
+		fs.Usage()
+		return errors.New("missing required -dir argument")
+	}
+	if *output != outputTable && *output != outputJSON {
+		return fmt.Errorf("output format '%s' not supported. Supported formats: %v", *output,
+			[]string{outputTable, outputJSON})
+	}
+
+	runs, err := snapshot.NewStore(*dir).List()
+	if err != nil {
+		return fmt.Errorf("failed to read snapshots: %w", err)
+	}
+
+	doc := historyDocument{Runs: []runSummary{}, Instances: []snapshot.InstanceHistory{}}
+	for _, r := range runs {
+		doc.Runs = append(doc.Runs, runSummary{r.ID, r.StartedAt, r.Files, r.StateHash, r.Partial, r.Summary})
+	}
+	for _, h := range snapshot.History(runs) {
+		if *instanceID == "" || h.InstanceID == *instanceID {
+			doc.Instances = append(doc.Instances, h)
+		}
+	}
+
+	if *output == outputJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	}
+	printHistory(out, runs, doc.Instances, now)
+	return nil
+}
+
+// printHistory renders the runs and instance histories as tables.
+func printHistory(out io.Writer, runs []snapshot.Run, instances []snapshot.InstanceHistory, now time.Time) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "RUN\tSTARTED\tSTATE\tTOTAL\tDRIFTED\tMISSING STATE\tMISSING LIVE\tSUPPRESSED\tPARTIAL\tFILES")
+	for _, r := range runs {
+		s := r.Summary
+		fmt.Fprintf(w, "%s\t%s\t%.12s\t%d\t%d\t%d\t%d\t%d\t%s\t%s\n", r.ID, r.StartedAt.Format(time.RFC3339),
+			r.StateHash, s.Total, s.Drifted, s.MissingState, s.MissingLive, s.Suppressed, orDash(r.Partial), strings.Join(r.Files, ", "))
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "INSTANCE\tADDRESS\tSINCE\tRESOLVED\tDURATION\tCOMMENT\tDRIFTS")
+	for _, h := range instances {
+		for _, p := range h.Periods {
+			resolved, until := "-", now
+			if p.Resolved != nil {
+				resolved, until = p.Resolved.Format(time.RFC3339), *p.Resolved
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", h.InstanceID, orDash(h.Address),
+				p.Since.Format(time.RFC3339), resolved, until.Sub(p.Since).Round(time.Minute),
+				p.Comment, strings.Join(p.Drifts, ", "))
+		}
+	}
+	w.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
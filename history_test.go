@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tpriime/ec2diff/pkg"
+	"github.com/tpriime/ec2diff/pkg/aws"
+	"github.com/tpriime/ec2diff/pkg/drift"
+	"github.com/tpriime/ec2diff/pkg/mocks"
+	"github.com/tpriime/ec2diff/registry"
+)
+
+func TestExecute_SavesSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	state := pkg.InstanceMap{"i-1": pkg.Instance{ID: "i-1", State: "running", Address: "aws_instance.web"}}
+
+	for _, liveState := range []string{"stopped", "running"} {
+		cfg := &Config{
+			FilePaths:     []string{"data.tfstate"},
+			Attributes:    []string{pkg.AttrInstanceState},
+			Registry:      registry.NewParserRegistry([]pkg.Parser{&mocks.MockParser{Parsed: state, Extensions: []string{".tfstate"}}}),
+			Fetcher:       &mocks.MockLiveFetcher{Instances: pkg.InstanceMap{"i-1": pkg.Instance{ID: "i-1", State: liveState}}},
+			Checker:       drift.NewDriftChecker(1),
+			ReportPrinter: &mocks.MockReportPrinter{},
+			HelpFn:        func() {},
+			SaveDir:       dir,
+		}
+		assert.NoError(t, execute(t.Context(), cfg))
+	}
+
+	var out bytes.Buffer
+	err := runHistory([]string{"-dir", dir, "-output", "json"}, &out, time.Now())
+
+	assert.NoError(t, err)
+	var doc historyDocument
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &doc))
+	assert.Len(t, doc.Runs, 2)
+	assert.Equal(t, []string{"data.tfstate"}, doc.Runs[0].Files)
+	assert.NotEmpty(t, doc.Runs[0].StateHash)
+	assert.Len(t, doc.Instances, 1)
+	assert.Equal(t, "aws_instance.web", doc.Instances[0].Address)
+	assert.Equal(t, doc.Runs[0].StartedAt, doc.Instances[0].Periods[0].Since)
+	assert.Equal(t, doc.Runs[1].StartedAt, *doc.Instances[0].Periods[0].Resolved)
+}
+
+func TestExecute_SavesPartialSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	state := pkg.InstanceMap{
+		"i-1": pkg.Instance{ID: "i-1", State: "running"},
+		"i-2": pkg.Instance{ID: "i-2", State: "running"},
+	}
+	newConfig := func(maxDrifts int, pages ...pkg.InstanceMap) *Config {
+		return &Config{
+			FilePaths:     []string{"data.tfstate"},
+			Attributes:    []string{pkg.AttrInstanceState},
+			MaxDrifts:     maxDrifts,
+			Registry:      registry.NewParserRegistry([]pkg.Parser{&mocks.MockParser{Parsed: state, Extensions: []string{".tfstate"}}}),
+			Fetcher:       &mocks.MockLiveFetcher{Pages: pages},
+			Checker:       drift.NewDriftChecker(1),
+			ReportPrinter: &mocks.MockReportPrinter{},
+			HelpFn:        func() {},
+			SaveDir:       dir,
+		}
+	}
+	stopped := pkg.InstanceMap{"i-1": pkg.Instance{ID: "i-1", State: "stopped"}}
+	assert.NoError(t, execute(t.Context(), newConfig(0, stopped, pkg.InstanceMap{"i-2": pkg.Instance{ID: "i-2", State: "stopped"}})))
+	assert.NoError(t, execute(t.Context(), newConfig(1, stopped, pkg.InstanceMap{"i-2": pkg.Instance{ID: "i-2", State: "running"}})))
+
+	var out bytes.Buffer
+	err := runHistory([]string{"-dir", dir, "-output", "json"}, &out, time.Now())
+
+	assert.NoError(t, err)
+	var doc historyDocument
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &doc))
+	assert.Len(t, doc.Runs, 2)
+	assert.Empty(t, doc.Runs[0].Partial)
+	assert.Equal(t, "stopped after 1 drift", doc.Runs[1].Partial)
+	if assert.Len(t, doc.Instances, 2) {
+		assert.Nil(t, doc.Instances[1].Periods[0].Resolved, "i-2 was not checked by the stopped run")
+	}
+}
+
+func TestPartialReason(t *testing.T) {
+	assert.Empty(t, partialReason(&Config{Scope: scopeBoth}, false, nil))
+	assert.Equal(t, "stopped after 5 drifts", partialReason(&Config{MaxDrifts: 5}, true, nil))
+	assert.Equal(t, "stopped after 1 drift", partialReason(&Config{MaxDrifts: 1}, true, nil))
+	assert.Equal(t, "live instances filtered", partialReason(&Config{Filters: []aws.Filter{{Name: "tag:Env"}}}, false, nil))
+	assert.Equal(t, "restricted to instance IDs", partialReason(&Config{InstanceIDs: []string{"i-1"}}, false, nil))
+	assert.Equal(t, "state scope", partialReason(&Config{Scope: scopeState}, false, nil))
+	assert.Equal(t, "1 page not fetched", partialReason(&Config{}, true, []pkg.FailedPage{{Page: 2}}))
+	assert.Equal(t, "2 pages not fetched", partialReason(&Config{}, true, []pkg.FailedPage{{Page: 2}, {Page: 3}}))
+}
+
+func TestRunHistory_Table(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &Config{
+		FilePaths:     []string{"data.tfstate"},
+		Attributes:    []string{pkg.AttrInstanceState},
+		Registry:      registry.NewParserRegistry([]pkg.Parser{&mocks.MockParser{Parsed: pkg.InstanceMap{}, Extensions: []string{".tfstate"}}}),
+		Fetcher:       &mocks.MockLiveFetcher{Instances: pkg.InstanceMap{"i-1": pkg.Instance{ID: "i-1"}}},
+		Checker:       drift.NewDriftChecker(1),
+		ReportPrinter: &mocks.MockReportPrinter{},
+		HelpFn:        func() {},
+		SaveDir:       dir,
+	}
+	assert.NoError(t, execute(t.Context(), cfg))
+
+	var out bytes.Buffer
+	err := runHistory([]string{"-dir", dir}, &out, time.Now().Add(2*time.Hour))
+
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "data.tfstate")
+	assert.Contains(t, out.String(), "i-1")
+	assert.Contains(t, out.String(), pkg.CommentMissingState)
+	assert.Contains(t, out.String(), "2h0m0s", "ongoing drift should be aged up to now")
+}
+
+func TestRunHistory_Args(t *testing.T) {
+	err := run(t.Context(), []string{"history"}, &bytes.Buffer{})
+	assert.ErrorContains(t, err, "missing required -dir argument")
+
+	err = runHistory([]string{"-dir", t.TempDir(), "-output", "xml"}, &bytes.Buffer{}, time.Now())
+	assert.ErrorContains(t, err, "not supported")
+}
@@ -6,16 +6,23 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"maps"
 	"os"
-	"path/filepath"
+	"runtime"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/tpriime/ec2diff/pkg"
 	"github.com/tpriime/ec2diff/pkg/aws"
 	"github.com/tpriime/ec2diff/pkg/drift"
+	"github.com/tpriime/ec2diff/pkg/hclparser"
+	"github.com/tpriime/ec2diff/pkg/jsonprinter"
 	"github.com/tpriime/ec2diff/pkg/logger"
+	"github.com/tpriime/ec2diff/pkg/snapshot"
+	"github.com/tpriime/ec2diff/pkg/suppress"
 	"github.com/tpriime/ec2diff/pkg/tableprinter"
+	"github.com/tpriime/ec2diff/pkg/tagglob"
 	"github.com/tpriime/ec2diff/pkg/tfstate"
 	"github.com/tpriime/ec2diff/registry"
 )
@@ -24,43 +31,135 @@ const (
 	// Page size per remote or live fetch. Must be greater than 5
 	fetchPageSize = 100
 
-	// Number of concurrent workers. Tune this based on system capacity
-	driftCheckWorkers = 4
+	// Number of fetched pages buffered ahead of the drift checks
+	prefetchPages = 2
 )
 
+// comparison scopes
+const (
+	scopeState = "state" // only instances managed by the state
+	scopeLive  = "live"  // only instances found live
+	scopeBoth  = "both"  // instances from either side
+)
+
+// process exit codes, modeled on `terraform plan -detailed-exitcode`
+const (
+	exitNoDrift = 0
+	exitError   = 1
+	exitDrift   = 2
+	exitMissing = 3
+	exitPartial = 4 // pages of live instances could not be fetched
+)
+
+// output formats
+const (
+	outputTable  = "table"
+	outputJSON   = "json"
+	outputNDJSON = "ndjson"
+)
+
+// flags configuring AWS calls, which do not apply to -live-file
+var awsFlags = []string{"regions", "profiles", "assume-role", "rps", "retry-mode", "max-attempts", "max-backoff", "call-timeout"}
+
 func main() {
-	logger.Init(logger.LevelInfo)
-	ctx := logger.With(context.Background())
+	logger.Init(os.Stderr, logger.LevelInfo, logger.FormatText)
 
-	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
-		logger.Error(ctx, "Program terminated with error", "error", err)
-		os.Exit(1)
+	if err := run(context.Background(), os.Args[1:], os.Stdout); err != nil {
+		var driftErr *driftExitError
+		if errors.As(err, &driftErr) {
+			os.Exit(driftErr.code)
+		}
+		os.Exit(exitError)
 	}
 }
 
+// driftExitError is returned when -detailed-exitcode is set and drift was found, or
+// when the report is partial. It carries the exit code rather than signalling a failure of the program.
+type driftExitError struct {
+	code int
+}
+
+func (e *driftExitError) Error() string {
+	if e.code == exitPartial {
+		return fmt.Sprintf("report is partial (exit code %d)", e.code)
+	}
+	return fmt.Sprintf("drift detected (exit code %d)", e.code)
+}
+
 // Config holds parsed inputs and injected dependencies for drift checking.
 type Config struct {
 	// CLI args
-	FilePath   string   // Path to HCL or tfstate file
-	Attributes []string // EC2 attributes to compare
-	ShowHelp   bool     // Whether to display CLI help
-	ListAttrs  bool     // Whether to list supported attributes
+	FilePaths        []string      // Paths, globs, directories or remote URIs of HCL, Terraform config or tfstate files
+	Attributes       []string      // EC2 attributes to compare
+	ShowHelp         bool          // Whether to display CLI help
+	ListAttrs        bool          // Whether to list supported attributes
+	Output           string        // Report output format
+	LogLevel         string        // Minimum level of logs to write
+	LogFormat        string        // Log encoding, text or json
+	LogFile          string        // Path to write logs to instead of stderr
+	Filters          []aws.Filter  // EC2 filters applied to the live fetch
+	InstanceIDs      []string      // Instance IDs to restrict the run to
+	Scope            string        // Which instances to compare: state, live or both
+	Regions          []string      // AWS regions to fetch from, or "all"
+	Profiles         []string      // AWS shared config profiles to fetch with
+	AssumeRoles      []string      // IAM role ARNs to assume, one per profile or all with the only profile
+	MaxDrifts        int           // Stop fetching once this many drifts are found, if positive
+	DetailedExitCode bool          // Whether to exit with a code reflecting the drift found
+	IgnoreFile       string        // Path to the suppression file. Defaults to .ec2diffignore, if present
+	IgnoreTags       []string      // Globs of tag keys left out of the comparison
+	SaveDir          string        // Directory to save a snapshot of the run to, if set
+	LiveFile         string        // Saved DescribeInstances output to read instead of calling AWS
+	RetryMode        string        // AWS SDK retry mode, standard or adaptive. Defaults to the AWS config
+	MaxAttempts      int           // Attempts per AWS call, retries included. Defaults to the AWS config
+	MaxBackoff       time.Duration // Maximum delay between retries. Defaults to the SDK's
+	RequestRate      float64       // AWS requests per second per account and region, if positive
+	CallTimeout      time.Duration // Timeout of each AWS call attempt, if positive
+	Workers          int           // Number of concurrent drift check workers
 
 	// Dependencies
 	Registry      *registry.ParserRegistry
+	Sources       *registry.SourceRegistry
 	Fetcher       pkg.PaginatedLiveFetcher
 	Checker       pkg.DriftChecker
+	Suppressions  *suppress.Rules
 	ReportPrinter pkg.ReportPrinter
 	HelpFn        func()
 }
 
 // run parses flags and injects default dependencies before executing logic.
-func run(ctx context.Context, args []string, out io.Writer) error {
+// Errors that terminate the program are logged before the log output is closed.
+func run(ctx context.Context, args []string, out io.Writer) (err error) {
+	closeLog := func() error { return nil }
+	defer func() {
+		var driftErr *driftExitError
+		if err != nil && !errors.As(err, &driftErr) {
+			logger.Error(ctx, "Program terminated with error", "error", err)
+		}
+		closeLog()
+	}()
+
+	// Run subcommands
+	if len(args) > 0 {
+		switch args[0] {
+		case "history":
+			return runHistory(args[1:], out, time.Now())
+		case "compare":
+			return runCompare(args[1:], out)
+		}
+	}
+
 	cfg, err := parseFlags(args, out)
 	if err != nil {
 		return err
 	}
 
+	logCloser, err := initLogger(cfg)
+	if err != nil {
+		return err
+	}
+	closeLog = logCloser
+	ctx = logger.NewContext(ctx) // Log with the configured logger, even if ctx carries another
+
 	// Show help and exit
 	if cfg.ShowHelp {
 		cfg.HelpFn()
@@ -80,13 +179,41 @@ func run(ctx context.Context, args []string, out io.Writer) error {
 	logger.Debug(ctx, "initalzing dependencies")
 	cfg.Registry = registry.NewParserRegistry([]pkg.Parser{
 		tfstate.NewTfStateParser(),
+		hclparser.NewHclParser(),
 	})
-	cfg.Fetcher, err = aws.NewAwsFetcher(ctx, fetchPageSize)
+	cfg.Sources = newSourceRegistry()
+	cfg.ReportPrinter, err = newReportPrinter(cfg.Output, out)
 	if err != nil {
-		return fmt.Errorf("failed to init AWS client: %w", err)
+		return err
+	}
+	if cfg.LiveFile != "" {
+		cfg.Fetcher, err = aws.NewFileFetcher(cfg.LiveFile,
+			aws.WithFilters(cfg.Filters...),
+			aws.WithInstanceIDs(cfg.InstanceIDs...),
+		)
+		if err != nil {
+			return err
+		}
+	} else {
+		cfg.Fetcher, err = aws.NewAwsFetcher(ctx, fetchPageSize,
+			aws.WithFilters(cfg.Filters...),
+			aws.WithInstanceIDs(cfg.InstanceIDs...),
+			aws.WithRegions(cfg.Regions...),
+			aws.WithProfiles(cfg.Profiles...),
+			aws.WithAssumeRoles(cfg.AssumeRoles...),
+			aws.WithRetries(cfg.RetryMode, cfg.MaxAttempts, cfg.MaxBackoff),
+			aws.WithRateLimit(cfg.RequestRate),
+			aws.WithCallTimeout(cfg.CallTimeout),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to init AWS client: %w", err)
+		}
+	}
+	cfg.Checker = drift.NewDriftChecker(cfg.Workers, drift.WithIgnoredTags(cfg.IgnoreTags...))
+	cfg.Suppressions, err = loadSuppressions(ctx, cfg.IgnoreFile)
+	if err != nil {
+		return err
 	}
-	cfg.Checker = drift.NewDriftChecker(driftCheckWorkers)
-	cfg.ReportPrinter = tableprinter.NewTablePrinter(out)
 
 	return execute(ctx, cfg)
 }
@@ -96,21 +223,126 @@ func parseFlags(args []string, out io.Writer) (*Config, error) {
 	fs := flag.NewFlagSet("ec2diff", flag.ContinueOnError)
 	fs.SetOutput(out)
 
-	file := fs.String("file", "", "Path to file (.hcl or .tfstate).")
+	var files repeatedFlag
+	fs.Var(&files, "file", "Path to file (.hcl, .tf or .tfstate), glob, directory or remote state URI. Can be repeated.")
 	attrs := fs.String("attrs", "", "Comma-separated attributes to check.")
 	listAttrs := fs.Bool("list-attributes", false, "List supported attributes.")
+	output := fs.String("output", outputTable, "Report format: table, json or ndjson.")
+	logLevel := fs.String("log-level", "info", "Log level: debug, info, warn, error or silent.")
+	logFormat := fs.String("log-format", string(logger.FormatText), "Log format: text or json.")
+	logFile := fs.String("log-file", "", "Path to write logs to. Defaults to stderr.")
+	detailedExitCode := fs.Bool("detailed-exitcode", false,
+		"Exit with 0 for no drift, 2 for drift, 3 for instances missing in state or live, 1 for errors.")
+	var filters repeatedFlag
+	fs.Var(&filters, "filter", "EC2 filter as name=value1,value2 (e.g. tag:Env=prod). Can be repeated.")
+	instanceIDs := fs.String("instance-ids", "", "Comma-separated instance IDs to check.")
+	scope := fs.String("scope", scopeBoth,
+		"Instances to compare: state (managed only), live (skip missing live) or both.")
+	regions := fs.String("regions", "", "Comma-separated AWS regions to check, or 'all' for every enabled region.")
+	profiles := fs.String("profiles", "", "Comma-separated AWS profiles to check.")
+	assumeRoles := fs.String("assume-role", "", "Comma-separated IAM role ARNs to assume, with the only profile or the profile at the same position.")
+	maxDrifts := fs.Int("max-drifts", 0, "Stop fetching once this many drifted or missing instances are found. 0 means no limit.")
+	failFast := fs.Bool("fail-fast", false, "Stop fetching at the first drift. Same as -max-drifts 1, and cannot be combined with it.")
+	ignoreFile := fs.String("ignore-file", "", "Path to a YAML or HCL file of drifts to suppress. Defaults to "+suppress.DefaultFile+", if present.")
+	ignoreTags := fs.String("ignore-tags", "", "Comma-separated globs of tag keys to leave out of the comparison (e.g. aws:*).")
+	saveDir := fs.String("save", "", "Directory to save a snapshot of the run to, for 'ec2diff history'.")
+	liveFile := fs.String("live-file", "", "Saved 'aws ec2 describe-instances' output to compare against, instead of calling AWS.")
+	retryMode := fs.String("retry-mode", "", "AWS retry mode: standard or adaptive. Defaults to AWS_RETRY_MODE, or standard.")
+	maxAttempts := fs.Int("max-attempts", 0, "Attempts per AWS call, retries included. Defaults to AWS_MAX_ATTEMPTS, or 3.")
+	maxBackoff := fs.Duration("max-backoff", 0, "Maximum delay between retries, which back off exponentially with jitter. Defaults to 20s.")
+	requestRate := fs.Float64("rps", 0, "Maximum AWS requests per second, per account and region. 0 means no limit.")
+	callTimeout := fs.Duration("call-timeout", 0, "Timeout of each AWS call attempt, e.g. 10s. 0 means no timeout.")
+	workers := fs.Int("workers", runtime.NumCPU(), "Number of concurrent drift check workers.")
 	showHelp := fs.Bool("h", false, "Show help.")
 
 	if err := fs.Parse(args); err != nil {
 		return nil, err
 	}
 
+	parsedFilters, err := parseFilters(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	if *maxDrifts < 0 {
+		return nil, fmt.Errorf("max-drifts must not be negative, got %d", *maxDrifts)
+	}
+	if *failFast {
+		maxDriftsSet := false
+		fs.Visit(func(f *flag.Flag) { maxDriftsSet = maxDriftsSet || f.Name == "max-drifts" })
+		if maxDriftsSet {
+			return nil, errors.New("fail-fast cannot be combined with max-drifts, as it stops at the first drift")
+		}
+		*maxDrifts = 1
+	}
+
+	for _, glob := range parseCommaSep(*ignoreTags) {
+		if err := tagglob.Validate(glob); err != nil {
+			return nil, fmt.Errorf("invalid tag glob '%s': %w", glob, err)
+		}
+	}
+
+	if *workers < 1 {
+		return nil, fmt.Errorf("workers must be at least 1, got %d", *workers)
+	}
+
+	if !slices.Contains([]string{"", "standard", "adaptive"}, *retryMode) {
+		return nil, fmt.Errorf("retry mode '%s' not supported. Supported modes: %v", *retryMode,
+			[]string{"standard", "adaptive"})
+	}
+	if *maxAttempts < 0 || *maxBackoff < 0 || *requestRate < 0 || *callTimeout < 0 {
+		return nil, errors.New("max-attempts, max-backoff, rps and call-timeout must not be negative")
+	}
+
+	if *liveFile != "" {
+		var set []string
+		fs.Visit(func(f *flag.Flag) {
+			if slices.Contains(awsFlags, f.Name) {
+				set = append(set, f.Name)
+			}
+		})
+		if len(set) > 0 {
+			return nil, fmt.Errorf("live-file cannot be combined with %s, as no AWS calls are made", strings.Join(set, ", "))
+		}
+	}
+
+	if p, r := len(parseCommaSep(*profiles)), len(parseCommaSep(*assumeRoles)); r > 0 && p > 1 && p != r {
+		return nil, fmt.Errorf("got %d profiles for %d roles to assume, give one profile or one per role", p, r)
+	}
+
+	if !slices.Contains([]string{scopeState, scopeLive, scopeBoth}, *scope) {
+		return nil, fmt.Errorf("scope '%s' not supported. Supported scopes: %v", *scope,
+			[]string{scopeState, scopeLive, scopeBoth})
+	}
+
 	cfg := &Config{
-		FilePath:   *file,
-		Attributes: parseCommaSep(*attrs),
-		ListAttrs:  *listAttrs,
-		ShowHelp:   *showHelp,
-		HelpFn:     fs.Usage,
+		FilePaths:        files,
+		Attributes:       parseCommaSep(*attrs),
+		ListAttrs:        *listAttrs,
+		Output:           *output,
+		LogLevel:         *logLevel,
+		LogFormat:        *logFormat,
+		LogFile:          *logFile,
+		Filters:          parsedFilters,
+		InstanceIDs:      parseCommaSep(*instanceIDs),
+		Scope:            *scope,
+		Regions:          parseCommaSep(*regions),
+		Profiles:         parseCommaSep(*profiles),
+		AssumeRoles:      parseCommaSep(*assumeRoles),
+		MaxDrifts:        *maxDrifts,
+		ShowHelp:         *showHelp,
+		HelpFn:           fs.Usage,
+		DetailedExitCode: *detailedExitCode,
+		IgnoreFile:       *ignoreFile,
+		IgnoreTags:       parseCommaSep(*ignoreTags),
+		SaveDir:          *saveDir,
+		LiveFile:         *liveFile,
+		RetryMode:        *retryMode,
+		MaxAttempts:      *maxAttempts,
+		MaxBackoff:       *maxBackoff,
+		RequestRate:      *requestRate,
+		CallTimeout:      *callTimeout,
+		Workers:          *workers,
 	}
 
 	return cfg, nil
@@ -118,7 +350,9 @@ func parseFlags(args []string, out io.Writer) (*Config, error) {
 
 // execute performs the parse, fetch, check and report logic based on the provided Config.
 func execute(ctx context.Context, cfg *Config) error {
-	if cfg.FilePath == "" {
+	startedAt := time.Now()
+
+	if len(cfg.FilePaths) == 0 {
 		cfg.HelpFn()
 		return errors.New("missing required -file argument")
 	}
@@ -129,48 +363,320 @@ func execute(ctx context.Context, cfg *Config) error {
 		cfg.Attributes = supportedAttributes() // Use all supported attributes if none are specified.
 	}
 
-	parser, ok := cfg.Registry.Get(cfg.FilePath)
-	if !ok {
-		return fmt.Errorf("no parser found for file extension %s", filepath.Ext(cfg.FilePath))
-	}
-
-	// Parse local state
-	state, err := parser.Parse(cfg.FilePath)
+	// Parse and merge local or remote states
+	state, err := loadStates(ctx, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to parse file: %w", err)
+		return err
 	}
 
-	logger.Info(ctx, fmt.Sprintf("Found %d instances in file", len(state)), "file", cfg.FilePath)
+	// Scope the state to the requested instances, as the live fetch is
+	if len(cfg.InstanceIDs) > 0 {
+		state = selectInstances(state, cfg.InstanceIDs)
+	}
 
-	// Fetch and compare instances
-	reports, err := fetchAndCompare(ctx, cfg, state)
+	// Fetch and compare instances, printing reports as each batch is checked
+	cfg.ReportPrinter.Begin()
+	reports, failed, stopped, err := fetchAndCompare(ctx, cfg, state)
 	if err != nil {
+		cfg.ReportPrinter.End(pkg.Summarize(reports), "", nil) // Close the reports printed so far
 		return fmt.Errorf("failed to check drifts: %w", err)
 	}
 
 	logger.Info(ctx, fmt.Sprintf("Generated %d reports in total", len(reports)))
+	partial := partialReason(cfg, stopped, failed)
+	cfg.ReportPrinter.End(pkg.Summarize(reports), partial, failed)
+
+	if cfg.SaveDir != "" {
+		snap := snapshot.NewRun(startedAt, cfg.FilePaths, cfg.Attributes, state, reports)
+		snap.FailedPages = failed
+		if snap.Partial = partial; snap.Partial != "" {
+			logger.Info(ctx, "Saving partial snapshot, instances not checked will not be counted as resolved", "reason", snap.Partial)
+		}
+		path, err := snapshot.NewStore(cfg.SaveDir).Save(snap)
+		if err != nil {
+			return err
+		}
+		logger.Info(ctx, "Saved snapshot", "file", path)
+	}
 
-	// Display report
-	cfg.ReportPrinter.Print(reports)
+	// A run with failed pages exits with its own code, as the drift found on the other pages is incomplete
+	if len(failed) > 0 {
+		logger.Warn(ctx, fmt.Sprintf("Report is partial, %s of live instances could not be fetched", count(len(failed), "page")))
+		return &driftExitError{code: exitPartial}
+	}
+
+	if cfg.DetailedExitCode {
+		if code := exitCode(pkg.Summarize(reports)); code != exitNoDrift {
+			return &driftExitError{code: code}
+		}
+	}
 
 	return nil
 }
 
-// fetchAndCompare fetches live ec2 resources and checks for drifts per page
-func fetchAndCompare(ctx context.Context, cfg *Config, state pkg.InstanceMap) ([]pkg.Report, error) {
-	reports := []pkg.Report{}
-	err := cfg.Fetcher.Fetch(ctx, func(page int, live pkg.InstanceMap) bool {
-		ctx := logger.With(ctx, "batch", page)
-		logger.Info(ctx, "Checking for drifts in batch...")
+// partialReason explains why a run left instances unchecked, so that history and compare
+// do not count them as resolved. It is empty if every instance was checked.
+func partialReason(cfg *Config, stopped bool, failed []pkg.FailedPage) string {
+	switch {
+	case len(failed) > 0:
+		return fmt.Sprintf("%s not fetched", count(len(failed), "page"))
+	case stopped:
+		return fmt.Sprintf("stopped after %s", count(cfg.MaxDrifts, "drift"))
+	case len(cfg.Filters) > 0:
+		return "live instances filtered"
+	case len(cfg.InstanceIDs) > 0:
+		return "restricted to instance IDs"
+	case cfg.Scope == scopeState || cfg.Scope == scopeLive:
+		return fmt.Sprintf("%s scope", cfg.Scope)
+	default:
+		return ""
+	}
+}
+
+// count formats n with noun, in the plural unless n is 1, e.g. "1 page" or "2 pages".
+func count(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// exitCode derives the detailed exit code from a report summary.
+// Missing instances take precedence over attribute drift.
+func exitCode(summary pkg.Summary) int {
+	switch {
+	case summary.MissingState > 0 || summary.MissingLive > 0:
+		return exitMissing
+	case summary.Drifted > 0 || summary.Conflicts > 0:
+		return exitDrift
+	default:
+		return exitNoDrift
+	}
+}
+
+// fetchAndCompare fetches live ec2 resources and checks for drifts per page.
+// Once all pages are fetched, state instances never seen live are reported as missing.
+// Reports are printed as each batch is checked, between the Begin and End of the printer by the caller.
+//
+// With the state scope, only instances managed by the state are fetched and checked.
+// With the live scope, instances missing live are not reported.
+// Fetching stops early once MaxDrifts reports with drift are found, in which case stopped is true.
+//
+// Pages that could not be fetched, even after retries, are returned rather than failing
+// the run, and the instances of the other pages are still checked.
+func fetchAndCompare(ctx context.Context, cfg *Config, state pkg.InstanceMap) (reports []pkg.Report, failed []pkg.FailedPage, stopped bool, err error) {
+	// Fetch pages ahead of the checks, which share one pool of workers
+	stop := make(chan struct{})
+	pages, fetched := prefetch(ctx, cfg, state, stop)
+
+	reports = []pkg.Report{}
+	drifts := 0
+	for checked := range cfg.Checker.CheckPages(ctx, pages, state, cfg.Attributes) {
+		if stopped {
+			continue // Drop the pages prefetched before the fetch stopped
+		}
+
+		ctx := logger.With(ctx, "batch", checked.Number)
+		logger.Info(ctx, "Checked batch for drifts")
 
-		// Check for drifts and report
-		rpts := cfg.Checker.CheckDrift(ctx, live, state, cfg.Attributes)
+		rpts := checked.Reports
+		suppressDrifts(cfg.Suppressions, rpts, checked.Instances, state)
+		for _, r := range rpts {
+			cfg.ReportPrinter.PrintReport(r)
+		}
 
 		reports = append(reports, rpts...)
-		return true
-	})
 
-	return reports, err
+		// Stop paging once enough new drift is found
+		for _, r := range rpts {
+			if r.Comment != pkg.CommentNoDriftDetected && !r.Suppressed {
+				drifts++
+			}
+		}
+		if cfg.MaxDrifts > 0 && drifts >= cfg.MaxDrifts {
+			logger.Info(ctx, fmt.Sprintf("Found %d drifts, stopping early", drifts), "max", cfg.MaxDrifts)
+			stopped = true
+			close(stop)
+		}
+	}
+
+	// Every page is checked, so the fetch is over
+	seen, err := fetched.seen, fetched.err
+	if err != nil {
+		var partial bool
+		if failed, partial = pkg.FailedPages(err); !partial {
+			return reports, nil, stopped, err
+		}
+		for _, p := range failed {
+			logger.Warn(ctx, "Failed to fetch page of live instances, the report will be partial",
+				"page", p.Page, "region", p.Region, "error", p.Error)
+		}
+	}
+
+	// Reconcile state instances that no page returned. Filters may exclude
+	// instances that do exist live, and failed pages may hold them, so they
+	// cannot be reported missing.
+	if cfg.Scope == scopeLive || stopped {
+		return reports, failed, stopped, nil
+	}
+	if len(cfg.Filters) > 0 {
+		logger.Info(ctx, "Skipping missing live check as live instances are filtered")
+		return reports, failed, stopped, nil
+	}
+	if len(failed) > 0 {
+		logger.Info(ctx, "Skipping missing live check as some pages could not be fetched")
+		return reports, failed, stopped, nil
+	}
+	missing := cfg.Checker.CheckMissingLive(ctx, seen, state, cfg.Attributes)
+	suppressDrifts(cfg.Suppressions, missing, nil, state)
+	for _, r := range missing {
+		cfg.ReportPrinter.PrintReport(r)
+	}
+	reports = append(reports, missing...)
+
+	return reports, nil, false, nil
+}
+
+// fetchResult is the outcome of a fetch started by prefetch. It is only set once the
+// fetch's pages channel is closed.
+type fetchResult struct {
+	seen map[string]struct{} // IDs of every live instance fetched, in scope or not
+	err  error
+}
+
+// prefetch fetches live instances in the background, sending each page on the returned
+// channel, which is closed once the fetch is over. Up to prefetchPages pages are fetched
+// ahead of the checks before the fetch waits for them. Closing stop ends the fetch.
+//
+// With the state scope, pages are narrowed down to the instances managed by the state.
+func prefetch(ctx context.Context, cfg *Config, state pkg.InstanceMap, stop <-chan struct{}) (<-chan pkg.Page, *fetchResult) {
+	pages := make(chan pkg.Page, prefetchPages)
+	result := &fetchResult{seen: map[string]struct{}{}}
+
+	onPage := func(page int, live pkg.InstanceMap) bool {
+		logger.Info(logger.With(ctx, "batch", page), "Fetched batch, queueing for drift checks...")
+
+		for id := range live {
+			result.seen[id] = struct{}{}
+		}
+		if cfg.Scope == scopeState {
+			live = managedInstances(live, state)
+		}
+
+		select {
+		case <-stop:
+			return false
+		default:
+		}
+		select {
+		case pages <- pkg.Page{Number: page, Instances: live}:
+			return true
+		case <-stop:
+			return false
+		}
+	}
+
+	go func() {
+		defer close(pages)
+		if idFetcher, ok := cfg.Fetcher.(pkg.IDLiveFetcher); ok && cfg.Scope == scopeState {
+			ids := slices.Sorted(maps.Keys(state))
+			logger.Info(ctx, fmt.Sprintf("Fetching %d instances managed by the state", len(ids)))
+			result.err = idFetcher.FetchByIDs(ctx, ids, onPage)
+		} else {
+			result.err = cfg.Fetcher.Fetch(ctx, onPage)
+		}
+	}()
+
+	return pages, result
+}
+
+// suppressDrifts applies the suppression rules to reports, matching tag
+// selectors against the live instance, or the state one if it is missing live.
+func suppressDrifts(rules *suppress.Rules, reports []pkg.Report, live, state pkg.InstanceMap) {
+	for i := range reports {
+		inst, ok := live[reports[i].InstanceID]
+		if !ok {
+			inst = state[reports[i].InstanceID]
+		}
+		rules.Apply(&reports[i], inst)
+	}
+}
+
+// loadSuppressions reads the suppression file, if one is given or found in the working directory.
+func loadSuppressions(ctx context.Context, path string) (*suppress.Rules, error) {
+	if path == "" {
+		if _, err := os.Stat(suppress.DefaultFile); err != nil {
+			return nil, nil
+		}
+		path = suppress.DefaultFile
+	}
+	rules, err := suppress.Load(ctx, path, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load suppression file: %w", err)
+	}
+	return rules, nil
+}
+
+// initLogger configures the default logger from the CLI args.
+// The returned function closes the log file, if one was opened.
+func initLogger(cfg *Config) (func() error, error) {
+	level, err := logger.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+	format, err := logger.ParseFormat(cfg.LogFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.LogFile == "" {
+		logger.Init(os.Stderr, level, format)
+		return func() error { return nil }, nil
+	}
+
+	f, err := os.OpenFile(cfg.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	logger.Init(f, level, format)
+	return f.Close, nil
+}
+
+// selectInstances returns the instances with the given IDs.
+func selectInstances(instances pkg.InstanceMap, ids []string) pkg.InstanceMap {
+	selected := pkg.InstanceMap{}
+	for _, id := range ids {
+		if inst, ok := instances[id]; ok {
+			selected[id] = inst
+		}
+	}
+	return selected
+}
+
+// managedInstances returns the live instances that are also in the state.
+func managedInstances(live, state pkg.InstanceMap) pkg.InstanceMap {
+	managed := pkg.InstanceMap{}
+	for id, inst := range live {
+		if _, ok := state[id]; ok {
+			managed[id] = inst
+		}
+	}
+	return managed
+}
+
+// newReportPrinter returns the report printer for the given output format.
+func newReportPrinter(format string, out io.Writer) (pkg.ReportPrinter, error) {
+	switch format {
+	case outputTable:
+		return tableprinter.NewTablePrinter(out), nil
+	case outputJSON:
+		return jsonprinter.NewJSONPrinter(out), nil
+	case outputNDJSON:
+		return jsonprinter.NewNDJSONPrinter(out), nil
+	default:
+		return nil, fmt.Errorf("output format '%s' not supported. Supported formats: %v", format,
+			[]string{outputTable, outputJSON, outputNDJSON})
+	}
 }
 
 // parseCommaSep splits a comma-separated string into a clean string slice.
@@ -189,6 +695,32 @@ func parseCommaSep(input string) []string {
 	return clean
 }
 
+// repeatedFlag collects the values of a flag that can be given more than once.
+type repeatedFlag []string
+
+func (r *repeatedFlag) String() string {
+	return strings.Join(*r, " ")
+}
+
+func (r *repeatedFlag) Set(value string) error {
+	*r = append(*r, value)
+	return nil
+}
+
+// parseFilters converts name=value1,value2 expressions into EC2 filters.
+func parseFilters(exprs []string) ([]aws.Filter, error) {
+	var filters []aws.Filter
+	for _, expr := range exprs {
+		name, values, ok := strings.Cut(expr, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || len(parseCommaSep(values)) == 0 {
+			return nil, fmt.Errorf("invalid filter '%s'. Expected name=value1,value2", expr)
+		}
+		filters = append(filters, aws.Filter{Name: name, Values: parseCommaSep(values)})
+	}
+	return filters, nil
+}
+
 // validateAttributes ensures each input attribute is supported.
 func validateAttributes(attrs []string) error {
 	if len(attrs) == 0 {
@@ -211,5 +743,15 @@ func supportedAttributes() []string {
 		pkg.AttrTags,
 		pkg.AttrSecurityGroups,
 		pkg.AttrPublicIP,
+		pkg.AttrPrivateIP,
+		pkg.AttrAmi,
+		pkg.AttrAvailabilityZone,
+		pkg.AttrSubnetID,
+		pkg.AttrVpcID,
+		pkg.AttrVpcSecurityGroupIDs,
+		pkg.AttrMonitoring,
+		pkg.AttrArchitecture,
+		pkg.AttrVirtualizationType,
+		pkg.AttrIamInstanceProfile,
 	}
 }
@@ -4,11 +4,27 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/tpriime/ec2diff/pkg"
+	"github.com/tpriime/ec2diff/pkg/aws"
+	"github.com/tpriime/ec2diff/pkg/drift"
+	"github.com/tpriime/ec2diff/pkg/fakeec2"
+	"github.com/tpriime/ec2diff/pkg/jsonprinter"
+	"github.com/tpriime/ec2diff/pkg/logger"
 	"github.com/tpriime/ec2diff/pkg/mocks"
+	"github.com/tpriime/ec2diff/pkg/snapshot"
+	"github.com/tpriime/ec2diff/pkg/suppress"
+	"github.com/tpriime/ec2diff/pkg/tfstate"
 	"github.com/tpriime/ec2diff/registry"
 )
 
@@ -41,6 +57,86 @@ func TestRun_Args(t *testing.T) {
 		assert.Contains(t, err.Error(), "not supported")
 	})
 
+	t.Run("should reject unsupported output format", func(t *testing.T) {
+		var out bytes.Buffer
+		err := run(t.Context(), []string{"-file", "test.tfstate", "-output", "xml"}, &out)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "not supported")
+	})
+
+	t.Run("should reject unsupported log level", func(t *testing.T) {
+		var out bytes.Buffer
+		err := run(t.Context(), []string{"-file", "test.tfstate", "-log-level", "loud"}, &out)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "not supported")
+	})
+
+	t.Run("should write logs to file and not to output", func(t *testing.T) {
+		logFile := filepath.Join(t.TempDir(), "ec2diff.log")
+
+		var out bytes.Buffer
+		err := run(t.Context(), []string{
+			"-file", "missing.tfstate",
+			"-log-file", logFile,
+			"-log-format", "json",
+		}, &out)
+
+		assert.Error(t, err)
+		assert.NotContains(t, out.String(), "Program terminated")
+
+		logs, _ := os.ReadFile(logFile)
+		assert.Contains(t, string(logs), `"msg":"Program terminated with error"`)
+	})
+
+	t.Run("should configure logs of a context carrying a logger", func(t *testing.T) {
+		var startup bytes.Buffer
+		logger.Init(&startup, logger.LevelInfo, logger.FormatText)
+		ctx := logger.With(t.Context())
+		logFile := filepath.Join(t.TempDir(), "ec2diff.log")
+
+		err := run(ctx, []string{
+			"-file", "examples/resources/terraform.tfstate",
+			"-live-file", "examples/resources/aws_ec2_response_full.json",
+			"-log-file", logFile,
+			"-log-format", "json",
+			"-log-level", "warn",
+		}, &bytes.Buffer{})
+
+		assert.NoError(t, err)
+		assert.Empty(t, startup.String(), "logs should not go to the startup logger")
+		logs, _ := os.ReadFile(logFile)
+		assert.NotContains(t, string(logs), `"level":"INFO"`)
+		assert.NotContains(t, string(logs), "level=")
+	})
+
+	t.Run("should compare against a saved live file", func(t *testing.T) {
+		var out bytes.Buffer
+		err := run(t.Context(), []string{
+			"-file", "examples/resources/terraform.tfstate",
+			"-live-file", "examples/resources/aws_ec2_response_full.json",
+			"-scope", "both",
+			"-output", "json",
+			"-log-level", "silent",
+		}, &out)
+
+		assert.NoError(t, err)
+		assert.Contains(t, out.String(), `"instance_id": "i-0846f159803a92a1a"`)
+		assert.Contains(t, out.String(), `"instance_id": "i-0f3d4bc78c78cc67b"`)
+	})
+
+	t.Run("should reject filters with a live file", func(t *testing.T) {
+		var out bytes.Buffer
+		err := run(t.Context(), []string{
+			"-file", "examples/resources/terraform.tfstate",
+			"-live-file", "examples/resources/aws_ec2_response_full.json",
+			"-filter", "tag:Env=prod",
+		}, &out)
+
+		assert.EqualError(t, err, "filter tag:Env is not supported with a saved response")
+	})
+
 	t.Run("should reject missing file and print usage", func(t *testing.T) {
 		var out bytes.Buffer
 		err := run(t.Context(), []string{"-instances", "i-123"}, &out)
@@ -50,6 +146,32 @@ func TestRun_Args(t *testing.T) {
 	})
 }
 
+func TestRun_Endpoint(t *testing.T) {
+	server := fakeec2.NewServer()
+	t.Cleanup(server.Close)
+	server.SetEnv(t)
+	server.AddInstances(types.Instance{
+		InstanceId:   awssdk.String("i-0846f159803a92a1a"),
+		InstanceType: types.InstanceTypeT2Large,
+	})
+
+	var out bytes.Buffer
+	err := run(t.Context(), []string{
+		"-file", "examples/resources/terraform.tfstate",
+		"-attrs", "instance_type",
+		"-output", "json",
+		"-log-level", "silent",
+		"-detailed-exitcode",
+	}, &out)
+
+	var driftErr *driftExitError
+	assert.ErrorAs(t, err, &driftErr)
+	assert.Contains(t, out.String(), `"instance_id": "i-0846f159803a92a1a"`)
+	assert.Contains(t, out.String(), `"expected": "t2.large"`)
+	assert.Contains(t, out.String(), `"found": "t2.micro"`)
+	assert.NotEmpty(t, server.Requests(), "instances should be fetched from the endpoint")
+}
+
 func TestExecute_SuccessfulWithNoDrifts(t *testing.T) {
 	state := pkg.InstanceMap{"i-abc": pkg.Instance{ID: "i-abc", State: "running"}}
 	live := pkg.InstanceMap{"i-abc": pkg.Instance{ID: "i-abc", State: "running"}}
@@ -59,7 +181,7 @@ func TestExecute_SuccessfulWithNoDrifts(t *testing.T) {
 	printer := &mocks.MockReportPrinter{}
 
 	cfg := &Config{
-		FilePath:      "data.tfstate",
+		FilePaths:     []string{"data.tfstate"},
 		Attributes:    []string{pkg.AttrInstanceState},
 		Registry:      registry.NewParserRegistry([]pkg.Parser{parser}),
 		Fetcher:       fetcher,
@@ -76,11 +198,109 @@ func TestExecute_SuccessfulWithNoDrifts(t *testing.T) {
 	assert.Empty(t, printer.Output[0].Drifts)
 }
 
+func TestExecute_ReportsMissingLive(t *testing.T) {
+	state := pkg.InstanceMap{
+		"i-abc":  pkg.Instance{ID: "i-abc", State: "running"},
+		"i-gone": pkg.Instance{ID: "i-gone", State: "running"},
+	}
+	live := pkg.InstanceMap{"i-abc": pkg.Instance{ID: "i-abc", State: "running"}}
+
+	printer := &mocks.MockReportPrinter{}
+	cfg := &Config{
+		FilePaths:     []string{"data.tfstate"},
+		Attributes:    []string{pkg.AttrInstanceState},
+		Registry:      registry.NewParserRegistry([]pkg.Parser{&mocks.MockParser{Parsed: state, Extensions: []string{".tfstate"}}}),
+		Fetcher:       &mocks.MockLiveFetcher{Instances: live},
+		Checker:       drift.NewDriftChecker(1),
+		ReportPrinter: printer,
+		HelpFn:        func() {},
+	}
+
+	err := execute(context.Background(), cfg)
+
+	assert.NoError(t, err)
+	assert.Len(t, printer.Output, 2)
+	assert.Equal(t, "i-gone", printer.Output[1].InstanceID)
+	assert.Equal(t, pkg.CommentMissingLive, printer.Output[1].Comment)
+}
+
+func TestExecute_RemoteState(t *testing.T) {
+	source := &mocks.MockStateSource{
+		Schemes: []string{"s3"},
+		Data:    []byte(`{"resources":[{"type":"aws_instance","instances":[{"attributes":{"id":"i-abc","instance_state":"running"}}]}]}`),
+	}
+	live := pkg.InstanceMap{"i-abc": pkg.Instance{ID: "i-abc", State: "stopped"}}
+
+	printer := &mocks.MockReportPrinter{}
+	cfg := &Config{
+		FilePaths:     []string{"s3://tf-state/prod/terraform.tfstate"},
+		Attributes:    []string{pkg.AttrInstanceState},
+		Registry:      registry.NewParserRegistry([]pkg.Parser{tfstate.NewTfStateParser()}),
+		Sources:       registry.NewSourceRegistry([]pkg.StateSource{source}),
+		Fetcher:       &mocks.MockLiveFetcher{Instances: live},
+		Checker:       drift.NewDriftChecker(1),
+		ReportPrinter: printer,
+		HelpFn:        func() {},
+	}
+
+	err := execute(t.Context(), cfg)
+
+	assert.NoError(t, err)
+	assert.Len(t, printer.Output, 1)
+	assert.Equal(t, pkg.CommentDriftDetected, printer.Output[0].Comment)
+
+	cfg.FilePaths = []string{"gs://tf-state/prod/terraform.tfstate"}
+	assert.ErrorContains(t, execute(t.Context(), cfg), "unsupported state backend gs://")
+
+	cfg.FilePaths = []string{"s3://tf-state/prod/terraform.tfstate"}
+	source.Err = errors.New("access denied")
+	assert.ErrorContains(t, execute(t.Context(), cfg), "failed to read remote state: access denied")
+}
+
+func TestExecute_StreamsPages(t *testing.T) {
+	state := pkg.InstanceMap{
+		"i-abc":  pkg.Instance{ID: "i-abc", State: "running"},
+		"i-gone": pkg.Instance{ID: "i-gone", State: "running"},
+	}
+	live := pkg.InstanceMap{"i-abc": pkg.Instance{ID: "i-abc", State: "stopped"}}
+
+	var out bytes.Buffer
+	cfg := &Config{
+		FilePaths:     []string{"data.tfstate"},
+		Attributes:    []string{pkg.AttrInstanceState},
+		Registry:      registry.NewParserRegistry([]pkg.Parser{&mocks.MockParser{Parsed: state, Extensions: []string{".tfstate"}}}),
+		Fetcher:       &mocks.MockLiveFetcher{Instances: live},
+		Checker:       drift.NewDriftChecker(1),
+		ReportPrinter: jsonprinter.NewNDJSONPrinter(&out),
+		HelpFn:        func() {},
+	}
+
+	err := execute(context.Background(), cfg)
+
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 3, "each report should be printed exactly once, then the summary")
+	assert.Contains(t, lines[0], "i-abc")
+	assert.Contains(t, lines[1], "i-gone")
+	assert.Contains(t, lines[2], `"summary"`)
+}
+
+func TestNewReportPrinter(t *testing.T) {
+	for _, format := range []string{outputTable, outputJSON, outputNDJSON} {
+		p, err := newReportPrinter(format, &bytes.Buffer{})
+		assert.NoError(t, err)
+		assert.NotNil(t, p)
+	}
+
+	_, err := newReportPrinter("yaml", &bytes.Buffer{})
+	assert.Error(t, err)
+}
+
 func TestExecute_MissingFile(t *testing.T) {
 	called := false
 	cfg := &Config{
-		FilePath: "",
-		HelpFn:   func() { called = true },
+		FilePaths: nil,
+		HelpFn:    func() { called = true },
 	}
 	err := execute(context.Background(), cfg)
 	assert.Error(t, err)
@@ -90,8 +310,8 @@ func TestExecute_MissingFile(t *testing.T) {
 func TestExecute_UnsupportedExtension(t *testing.T) {
 	reg := registry.NewParserRegistry([]pkg.Parser{})
 	cfg := &Config{
-		FilePath: "unsupported.txt",
-		Registry: reg,
+		FilePaths: []string{"unsupported.txt"},
+		Registry:  reg,
 	}
 	err := execute(context.Background(), cfg)
 	assert.Error(t, err)
@@ -102,8 +322,8 @@ func TestExecute_ParseFails(t *testing.T) {
 	parser := &mocks.MockParser{Err: errors.New("broken"), Extensions: []string{".tfstate"}}
 	reg := registry.NewParserRegistry([]pkg.Parser{parser})
 	cfg := &Config{
-		FilePath: "file.tfstate",
-		Registry: reg,
+		FilePaths: []string{"file.tfstate"},
+		Registry:  reg,
 	}
 	err := execute(context.Background(), cfg)
 	assert.Error(t, err)
@@ -116,10 +336,12 @@ func TestExecute_FetchFails(t *testing.T) {
 	reg := registry.NewParserRegistry([]pkg.Parser{parser})
 
 	cfg := &Config{
-		FilePath: "file.tfstate",
-		Registry: reg,
-		Fetcher:  fetcher,
-		HelpFn:   func() {},
+		FilePaths:     []string{"file.tfstate"},
+		Registry:      reg,
+		Fetcher:       fetcher,
+		Checker:       drift.NewDriftChecker(1),
+		ReportPrinter: &mocks.MockReportPrinter{},
+		HelpFn:        func() {},
 	}
 
 	err := execute(context.Background(), cfg)
@@ -127,6 +349,108 @@ func TestExecute_FetchFails(t *testing.T) {
 	assert.Contains(t, err.Error(), "failed to check drifts")
 }
 
+func TestExecute_PartialReport(t *testing.T) {
+	state := pkg.InstanceMap{
+		"i-abc":   pkg.Instance{ID: "i-abc", State: "running"},
+		"i-later": pkg.Instance{ID: "i-later", State: "running"},
+	}
+	fetcher := &mocks.MockLiveFetcher{
+		Pages: []pkg.InstanceMap{{"i-abc": pkg.Instance{ID: "i-abc", State: "stopped"}}},
+		Err:   &pkg.PageError{Page: 2, Region: "us-east-1", Err: errors.New("RequestLimitExceeded")},
+	}
+
+	var out bytes.Buffer
+	cfg := &Config{
+		FilePaths:        []string{"data.tfstate"},
+		Attributes:       []string{pkg.AttrInstanceState},
+		Registry:         registry.NewParserRegistry([]pkg.Parser{&mocks.MockParser{Parsed: state, Extensions: []string{".tfstate"}}}),
+		Fetcher:          fetcher,
+		Checker:          drift.NewDriftChecker(1),
+		ReportPrinter:    jsonprinter.NewJSONPrinter(&out),
+		SaveDir:          t.TempDir(),
+		HelpFn:           func() {},
+		DetailedExitCode: true,
+	}
+
+	err := execute(context.Background(), cfg)
+
+	// The drift found on the fetched page does not hide that the report is partial
+	var driftErr *driftExitError
+	assert.ErrorAs(t, err, &driftErr)
+	assert.Equal(t, exitPartial, driftErr.code)
+	assert.Contains(t, out.String(), `"instance_id": "i-abc"`)
+	assert.NotContains(t, out.String(), "i-later", "instances on failed pages must not be reported missing")
+	assert.Contains(t, out.String(), `"failed_pages"`)
+	assert.Contains(t, out.String(), `"error": "RequestLimitExceeded"`)
+
+	runs, err := snapshot.NewStore(cfg.SaveDir).List()
+	assert.NoError(t, err)
+	assert.Len(t, runs, 1, "partial runs should be saved")
+	assert.Equal(t, "1 page not fetched", runs[0].Partial)
+	assert.Equal(t, []pkg.FailedPage{{Page: 2, Region: "us-east-1", Error: "RequestLimitExceeded"}}, runs[0].FailedPages)
+}
+
+// gatedFetcher serves its second page only once a report is printed, or gives up after a second.
+type gatedFetcher struct {
+	pages   []pkg.InstanceMap
+	printed chan struct{}
+}
+
+func (f *gatedFetcher) Fetch(_ context.Context, onPageFn func(page int, instances pkg.InstanceMap) bool) error {
+	for i, page := range f.pages {
+		if i > 0 {
+			select {
+			case <-f.printed:
+			case <-time.After(time.Second):
+				return errors.New("no report printed before the next page")
+			}
+		}
+		if !onPageFn(i+1, page) {
+			break
+		}
+	}
+	return nil
+}
+
+// signalingPrinter signals each report printed.
+type signalingPrinter struct {
+	mocks.MockReportPrinter
+	printed chan struct{}
+}
+
+func (p *signalingPrinter) PrintReport(report pkg.Report) {
+	p.MockReportPrinter.PrintReport(report)
+	p.printed <- struct{}{}
+}
+
+func TestExecute_StreamsReports(t *testing.T) {
+	state := pkg.InstanceMap{
+		"i-1": pkg.Instance{ID: "i-1", State: "running"},
+		"i-2": pkg.Instance{ID: "i-2", State: "running"},
+	}
+	printed := make(chan struct{}, 3)
+	printer := &signalingPrinter{printed: printed}
+	cfg := &Config{
+		FilePaths:  []string{"data.tfstate"},
+		Attributes: []string{pkg.AttrInstanceState},
+		Registry:   registry.NewParserRegistry([]pkg.Parser{&mocks.MockParser{Parsed: state, Extensions: []string{".tfstate"}}}),
+		Fetcher: &gatedFetcher{printed: printed, pages: []pkg.InstanceMap{
+			{"i-1": pkg.Instance{ID: "i-1", State: "stopped"}},
+			{"i-2": pkg.Instance{ID: "i-2", State: "running"}},
+		}},
+		Checker:       drift.NewDriftChecker(1),
+		ReportPrinter: printer,
+		HelpFn:        func() {},
+	}
+
+	err := execute(context.Background(), cfg)
+
+	assert.NoError(t, err, "the first report should be printed while the fetch is still running")
+	assert.Len(t, printer.Output, 2)
+	assert.True(t, printer.Ended)
+	assert.Equal(t, pkg.Summary{Total: 2, Drifted: 1, NoDrift: 1}, printer.Summary)
+}
+
 func TestParseCSV(t *testing.T) {
 	input := "id1,id2 , id3"
 	expected := []string{"id1", "id2", "id3"}
@@ -134,6 +458,250 @@ func TestParseCSV(t *testing.T) {
 	assert.Equal(t, expected, actual)
 }
 
+func TestParseFilters(t *testing.T) {
+	filters, err := parseFilters([]string{"tag:Env=prod", "instance-state-name=running, stopped"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []aws.Filter{
+		{Name: "tag:Env", Values: []string{"prod"}},
+		{Name: "instance-state-name", Values: []string{"running", "stopped"}},
+	}, filters)
+
+	for _, invalid := range []string{"vpc-id", "=vpc-1", "vpc-id="} {
+		_, err := parseFilters([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestParseFlags_Filters(t *testing.T) {
+	cfg, err := parseFlags([]string{
+		"-filter", "tag:Env=prod",
+		"-filter", "vpc-id=vpc-1",
+		"-instance-ids", "i-1,i-2",
+	}, &bytes.Buffer{})
+
+	assert.NoError(t, err)
+	assert.Len(t, cfg.Filters, 2)
+	assert.Equal(t, []string{"i-1", "i-2"}, cfg.InstanceIDs)
+}
+
+func TestParseFlags_Accounts(t *testing.T) {
+	cfg, err := parseFlags([]string{
+		"-regions", "us-east-1,eu-west-1",
+		"-profiles", "dev,prod",
+		"-assume-role", "arn:aws:iam::111111111111:role/audit,arn:aws:iam::222222222222:role/audit",
+	}, &bytes.Buffer{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"us-east-1", "eu-west-1"}, cfg.Regions)
+	assert.Equal(t, []string{"dev", "prod"}, cfg.Profiles)
+	assert.Equal(t, []string{"arn:aws:iam::111111111111:role/audit", "arn:aws:iam::222222222222:role/audit"}, cfg.AssumeRoles)
+
+	_, err = parseFlags([]string{
+		"-profiles", "dev,prod",
+		"-assume-role", "arn:aws:iam::111111111111:role/audit",
+	}, &bytes.Buffer{})
+	assert.ErrorContains(t, err, "give one profile or one per role")
+}
+
+func TestExecute_InstanceIDsScopeState(t *testing.T) {
+	state := pkg.InstanceMap{
+		"i-1": pkg.Instance{ID: "i-1", State: "running"},
+		"i-2": pkg.Instance{ID: "i-2", State: "running"},
+	}
+	printer := &mocks.MockReportPrinter{}
+	cfg := &Config{
+		FilePaths:     []string{"data.tfstate"},
+		Attributes:    []string{pkg.AttrInstanceState},
+		InstanceIDs:   []string{"i-1"},
+		Registry:      registry.NewParserRegistry([]pkg.Parser{&mocks.MockParser{Parsed: state, Extensions: []string{".tfstate"}}}),
+		Fetcher:       &mocks.MockLiveFetcher{Instances: pkg.InstanceMap{"i-1": state["i-1"]}},
+		Checker:       drift.NewDriftChecker(1),
+		ReportPrinter: printer,
+		HelpFn:        func() {},
+	}
+
+	err := execute(context.Background(), cfg)
+
+	assert.NoError(t, err)
+	assert.Len(t, printer.Output, 1, "i-2 is out of scope and should not be reported missing")
+}
+
+func TestExecute_FiltersSkipMissingLive(t *testing.T) {
+	state := pkg.InstanceMap{"i-stopped": pkg.Instance{ID: "i-stopped", State: "stopped"}}
+	printer := &mocks.MockReportPrinter{}
+	cfg := &Config{
+		FilePaths:     []string{"data.tfstate"},
+		Attributes:    []string{pkg.AttrInstanceState},
+		Filters:       []aws.Filter{{Name: "instance-state-name", Values: []string{"running"}}},
+		Registry:      registry.NewParserRegistry([]pkg.Parser{&mocks.MockParser{Parsed: state, Extensions: []string{".tfstate"}}}),
+		Fetcher:       &mocks.MockLiveFetcher{Instances: pkg.InstanceMap{}},
+		Checker:       drift.NewDriftChecker(1),
+		ReportPrinter: printer,
+		HelpFn:        func() {},
+	}
+
+	err := execute(context.Background(), cfg)
+
+	assert.NoError(t, err)
+	assert.Empty(t, printer.Output)
+}
+
+func TestExecute_Scope(t *testing.T) {
+	state := pkg.InstanceMap{
+		"i-1": pkg.Instance{ID: "i-1", State: "running"},
+		"i-2": pkg.Instance{ID: "i-2", State: "running"},
+	}
+	live := pkg.InstanceMap{
+		"i-1": pkg.Instance{ID: "i-1", State: "running"},
+		"i-3": pkg.Instance{ID: "i-3", State: "running"},
+	}
+
+	for scope, expected := range map[string]map[string]string{
+		scopeState: {"i-1": pkg.CommentNoDriftDetected, "i-2": pkg.CommentMissingLive},
+		scopeLive:  {"i-1": pkg.CommentNoDriftDetected, "i-3": pkg.CommentMissingState},
+		scopeBoth:  {"i-1": pkg.CommentNoDriftDetected, "i-2": pkg.CommentMissingLive, "i-3": pkg.CommentMissingState},
+	} {
+		t.Run(scope, func(t *testing.T) {
+			printer := &mocks.MockReportPrinter{}
+			fetcher := &mocks.MockLiveFetcher{Instances: live}
+			cfg := &Config{
+				FilePaths:     []string{"data.tfstate"},
+				Attributes:    []string{pkg.AttrInstanceState},
+				Scope:         scope,
+				Registry:      registry.NewParserRegistry([]pkg.Parser{&mocks.MockParser{Parsed: state, Extensions: []string{".tfstate"}}}),
+				Fetcher:       fetcher,
+				Checker:       drift.NewDriftChecker(1),
+				ReportPrinter: printer,
+				HelpFn:        func() {},
+			}
+
+			err := execute(context.Background(), cfg)
+
+			assert.NoError(t, err)
+			actual := map[string]string{}
+			for _, r := range printer.Output {
+				actual[r.InstanceID] = r.Comment
+			}
+			assert.Equal(t, expected, actual)
+			if scope == scopeState {
+				assert.Equal(t, []string{"i-1", "i-2"}, fetcher.FetchedIDs)
+			}
+		})
+	}
+}
+
+func TestExecute_MaxDrifts(t *testing.T) {
+	state := pkg.InstanceMap{
+		"i-1": pkg.Instance{ID: "i-1", State: "running"},
+		"i-2": pkg.Instance{ID: "i-2", State: "running"},
+		"i-3": pkg.Instance{ID: "i-3", State: "running"},
+		"i-4": pkg.Instance{ID: "i-4", State: "running"},
+	}
+	fetcher := &mocks.MockLiveFetcher{Pages: []pkg.InstanceMap{
+		{"i-1": pkg.Instance{ID: "i-1", State: "running"}},
+		{"i-2": pkg.Instance{ID: "i-2", State: "stopped"}},
+		{"i-3": pkg.Instance{ID: "i-3", State: "stopped"}},
+	}}
+	for n := 4; n <= 50; n++ {
+		id := fmt.Sprintf("i-%d", n)
+		state[id] = pkg.Instance{ID: id, State: "running"}
+		fetcher.Pages = append(fetcher.Pages, pkg.InstanceMap{id: pkg.Instance{ID: id, State: "stopped"}})
+	}
+	printer := &mocks.MockReportPrinter{}
+	cfg := &Config{
+		FilePaths:     []string{"data.tfstate"},
+		Attributes:    []string{pkg.AttrInstanceState},
+		MaxDrifts:     2,
+		Registry:      registry.NewParserRegistry([]pkg.Parser{&mocks.MockParser{Parsed: state, Extensions: []string{".tfstate"}}}),
+		Fetcher:       fetcher,
+		Checker:       drift.NewDriftChecker(1),
+		ReportPrinter: printer,
+		HelpFn:        func() {},
+	}
+
+	err := execute(context.Background(), cfg)
+
+	assert.NoError(t, err)
+	// Pages are fetched ahead of the checks, so how many are fetched past the second
+	// drift depends on scheduling, but only a few are
+	assert.Less(t, fetcher.Served, len(fetcher.Pages)/2, "fetching should stop at the second drift")
+	assert.Len(t, printer.Output, 3, "unfetched instances should not be reported missing live")
+	assert.Equal(t, "stopped after 2 drifts", printer.Partial, "the output should record that the run is partial")
+}
+
+func TestParseFlags_FailFast(t *testing.T) {
+	cfg, err := parseFlags([]string{"-fail-fast"}, &bytes.Buffer{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cfg.MaxDrifts)
+
+	_, err = parseFlags([]string{"-max-drifts", "-1"}, &bytes.Buffer{})
+	assert.Error(t, err)
+
+	_, err = parseFlags([]string{"-max-drifts", "5", "-fail-fast"}, &bytes.Buffer{})
+	assert.ErrorContains(t, err, "fail-fast cannot be combined with max-drifts")
+}
+
+func TestParseFlags_InvalidScope(t *testing.T) {
+	_, err := parseFlags([]string{"-scope", "account"}, &bytes.Buffer{})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not supported")
+}
+
+func TestParseFlags_Retries(t *testing.T) {
+	cfg, err := parseFlags([]string{
+		"-retry-mode", "adaptive",
+		"-max-attempts", "8",
+		"-max-backoff", "5s",
+		"-rps", "2.5",
+		"-call-timeout", "10s",
+	}, &bytes.Buffer{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "adaptive", cfg.RetryMode)
+	assert.Equal(t, 8, cfg.MaxAttempts)
+	assert.Equal(t, 5*time.Second, cfg.MaxBackoff)
+	assert.Equal(t, 2.5, cfg.RequestRate)
+	assert.Equal(t, 10*time.Second, cfg.CallTimeout)
+
+	_, err = parseFlags([]string{"-retry-mode", "eager"}, &bytes.Buffer{})
+	assert.ErrorContains(t, err, "not supported")
+
+	_, err = parseFlags([]string{"-rps", "-1"}, &bytes.Buffer{})
+	assert.ErrorContains(t, err, "must not be negative")
+}
+
+func TestParseFlags_LiveFileRejectsAWSFlags(t *testing.T) {
+	_, err := parseFlags([]string{"-live-file", "dump.json", "-regions", "all", "-rps", "5"}, &bytes.Buffer{})
+	assert.ErrorContains(t, err, "live-file cannot be combined with regions, rps")
+
+	_, err = parseFlags([]string{"-live-file", "dump.json", "-instance-ids", "i-1"}, &bytes.Buffer{})
+	assert.NoError(t, err)
+}
+
+func TestParseFlags_Workers(t *testing.T) {
+	cfg, err := parseFlags([]string{}, &bytes.Buffer{})
+	assert.NoError(t, err)
+	assert.Equal(t, runtime.NumCPU(), cfg.Workers)
+
+	cfg, err = parseFlags([]string{"-workers", "16"}, &bytes.Buffer{})
+	assert.NoError(t, err)
+	assert.Equal(t, 16, cfg.Workers)
+
+	_, err = parseFlags([]string{"-workers", "0"}, &bytes.Buffer{})
+	assert.ErrorContains(t, err, "workers must be at least 1")
+}
+
+func TestParseFlags_IgnoreTags(t *testing.T) {
+	cfg, err := parseFlags([]string{"-ignore-tags", "aws:*, karpenter.sh/*"}, &bytes.Buffer{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"aws:*", "karpenter.sh/*"}, cfg.IgnoreTags)
+
+	_, err = parseFlags([]string{"-ignore-tags", "["}, &bytes.Buffer{})
+	assert.ErrorContains(t, err, "invalid tag glob")
+}
+
 func TestValidateAttributes(t *testing.T) {
 	err := validateAttributes([]string{"instance_type", "instance_state", "tags", "security_groups"})
 	assert.NoError(t, err)
@@ -148,3 +716,112 @@ func TestSupportedAttributes(t *testing.T) {
 
 	assert.Contains(t, attrs, pkg.AttrInstanceType, "supportedAttributes should contain 'instance_type'")
 }
+
+func TestExecute_DetailedExitCode(t *testing.T) {
+	for name, tc := range map[string]struct {
+		state, live pkg.InstanceMap
+		code        int
+	}{
+		"no drift": {
+			state: pkg.InstanceMap{"i-1": pkg.Instance{ID: "i-1", State: "running"}},
+			live:  pkg.InstanceMap{"i-1": pkg.Instance{ID: "i-1", State: "running"}},
+			code:  exitNoDrift,
+		},
+		"drift": {
+			state: pkg.InstanceMap{"i-1": pkg.Instance{ID: "i-1", State: "running"}},
+			live:  pkg.InstanceMap{"i-1": pkg.Instance{ID: "i-1", State: "stopped"}},
+			code:  exitDrift,
+		},
+		"missing state": {
+			state: pkg.InstanceMap{"i-1": pkg.Instance{ID: "i-1", State: "running"}},
+			live: pkg.InstanceMap{
+				"i-1": pkg.Instance{ID: "i-1", State: "stopped"},
+				"i-2": pkg.Instance{ID: "i-2", State: "running"},
+			},
+			code: exitMissing,
+		},
+		"missing live": {
+			state: pkg.InstanceMap{"i-1": pkg.Instance{ID: "i-1", State: "running"}},
+			live:  pkg.InstanceMap{},
+			code:  exitMissing,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := &Config{
+				FilePaths:        []string{"data.tfstate"},
+				Attributes:       []string{pkg.AttrInstanceState},
+				Registry:         registry.NewParserRegistry([]pkg.Parser{&mocks.MockParser{Parsed: tc.state, Extensions: []string{".tfstate"}}}),
+				Fetcher:          &mocks.MockLiveFetcher{Instances: tc.live},
+				Checker:          drift.NewDriftChecker(1),
+				ReportPrinter:    &mocks.MockReportPrinter{},
+				HelpFn:           func() {},
+				DetailedExitCode: true,
+			}
+
+			err := execute(context.Background(), cfg)
+
+			if tc.code == exitNoDrift {
+				assert.NoError(t, err)
+				return
+			}
+			var driftErr *driftExitError
+			assert.ErrorAs(t, err, &driftErr)
+			assert.Equal(t, tc.code, driftErr.code)
+		})
+	}
+}
+
+func TestExecute_DriftWithoutDetailedExitCode(t *testing.T) {
+	cfg := &Config{
+		FilePaths:     []string{"data.tfstate"},
+		Attributes:    []string{pkg.AttrInstanceState},
+		Registry:      registry.NewParserRegistry([]pkg.Parser{&mocks.MockParser{Parsed: pkg.InstanceMap{}, Extensions: []string{".tfstate"}}}),
+		Fetcher:       &mocks.MockLiveFetcher{Instances: pkg.InstanceMap{"i-1": pkg.Instance{ID: "i-1"}}},
+		Checker:       drift.NewDriftChecker(1),
+		ReportPrinter: &mocks.MockReportPrinter{},
+		HelpFn:        func() {},
+	}
+
+	assert.NoError(t, execute(context.Background(), cfg))
+}
+
+func TestExecute_SuppressedDriftPasses(t *testing.T) {
+	state := pkg.InstanceMap{"i-1": pkg.Instance{ID: "i-1", State: "running"}}
+	live := pkg.InstanceMap{"i-1": pkg.Instance{ID: "i-1", State: "stopped", Tags: map[string]string{"aws:autoscaling:groupName": "web"}}}
+
+	printer := &mocks.MockReportPrinter{}
+	cfg := &Config{
+		FilePaths:        []string{"data.tfstate"},
+		Attributes:       []string{pkg.AttrInstanceState},
+		Registry:         registry.NewParserRegistry([]pkg.Parser{&mocks.MockParser{Parsed: state, Extensions: []string{".tfstate"}}}),
+		Fetcher:          &mocks.MockLiveFetcher{Instances: live},
+		Checker:          drift.NewDriftChecker(1),
+		Suppressions:     suppress.NewRules([]pkg.SuppressionRule{{Tags: map[string]string{"aws:autoscaling:groupName": "web"}}}),
+		ReportPrinter:    printer,
+		HelpFn:           func() {},
+		DetailedExitCode: true,
+	}
+
+	err := execute(t.Context(), cfg)
+
+	assert.NoError(t, err, "suppressed drift should not fail the run")
+	assert.Len(t, printer.Output, 1)
+	assert.True(t, printer.Output[0].Suppressed)
+	assert.Equal(t, pkg.CommentDriftDetected, printer.Output[0].Comment)
+}
+
+func TestLoadSuppressions(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	rules, err := loadSuppressions(t.Context(), "")
+	assert.NoError(t, err)
+	assert.Nil(t, rules, "no rules without a suppression file")
+
+	os.WriteFile(".ec2diffignore", []byte("rules:\n  - instance_id: i-1\n"), 0o644)
+	rules, err = loadSuppressions(t.Context(), "")
+	assert.NoError(t, err)
+	assert.NotNil(t, rules)
+
+	_, err = loadSuppressions(t.Context(), "missing.yaml")
+	assert.ErrorContains(t, err, "failed to load suppression file")
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/tpriime/ec2diff/pkg"
+	"github.com/tpriime/ec2diff/pkg/drift"
+	"github.com/tpriime/ec2diff/pkg/logger"
+)
+
+// Size of the benchmark fixture, paged as DescribeInstances would.
+const (
+	benchPages    = 100
+	benchPageSize = 100
+)
+
+// slowFetcher serves pages after a fixed delay each, standing in for DescribeInstances calls.
+type slowFetcher struct {
+	pages   []pkg.InstanceMap
+	latency time.Duration
+}
+
+func (f *slowFetcher) Fetch(ctx context.Context, onPageFn func(page int, instances pkg.InstanceMap) bool) error {
+	for i, page := range f.pages {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(f.latency):
+		}
+		if !onPageFn(i+1, page) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// discardPrinter drops every report, so that printing does not weigh on the benchmark.
+type discardPrinter struct{}
+
+func (discardPrinter) Begin()                                    {}
+func (discardPrinter) PrintReport(pkg.Report)                    {}
+func (discardPrinter) End(pkg.Summary, string, []pkg.FailedPage) {}
+
+// benchFixture generates the live pages and state of 10k instances, every other one drifted.
+func benchFixture() ([]pkg.InstanceMap, pkg.InstanceMap) {
+	state := pkg.InstanceMap{}
+	pages := make([]pkg.InstanceMap, benchPages)
+	for p := range pages {
+		pages[p] = pkg.InstanceMap{}
+		for i := range benchPageSize {
+			id := fmt.Sprintf("i-%08x", p*benchPageSize+i)
+			inst := pkg.Instance{
+				ID:             id,
+				Type:           "t3.micro",
+				State:          "running",
+				KeyName:        "deploy",
+				PublicIP:       fmt.Sprintf("10.0.%d.%d", p, i),
+				SecurityGroups: []string{"sg-web", "sg-ssh"},
+				Tags:           map[string]string{"Name": id, "Env": "prod", "Team": "platform"},
+			}
+			state[id] = inst
+			if i%2 == 0 {
+				inst.Type = "t3.large"
+				inst.Tags = map[string]string{"Name": id, "Env": "staging", "Team": "platform"}
+			}
+			pages[p][id] = inst
+		}
+	}
+	return pages, state
+}
+
+// BenchmarkFetchAndCompare compares checking each page once it is fetched, as drift
+// checks used to, with the pipeline fetching the next pages while earlier ones are checked.
+func BenchmarkFetchAndCompare(b *testing.B) {
+	logger.Init(io.Discard, logger.LevelSilent, logger.FormatText)
+	pages, state := benchFixture()
+	attributes := supportedAttributes()
+
+	for _, latency := range []time.Duration{0, 5 * time.Millisecond} {
+		fetcher := &slowFetcher{pages: pages, latency: latency}
+
+		b.Run(fmt.Sprintf("latency=%s/sequential", latency), func(b *testing.B) {
+			checker := drift.NewDriftChecker(4)
+			for b.Loop() {
+				var reports []pkg.Report
+				fetcher.Fetch(b.Context(), func(_ int, live pkg.InstanceMap) bool {
+					reports = append(reports, checker.CheckDrift(b.Context(), live, state, attributes)...)
+					return true
+				})
+			}
+		})
+
+		for _, workers := range []int{1, 4, 16} {
+			b.Run(fmt.Sprintf("latency=%s/pipelined/workers=%d", latency, workers), func(b *testing.B) {
+				cfg := &Config{
+					Attributes:    attributes,
+					Fetcher:       fetcher,
+					Checker:       drift.NewDriftChecker(workers),
+					ReportPrinter: discardPrinter{},
+				}
+				for b.Loop() {
+					if _, _, _, err := fetchAndCompare(b.Context(), cfg, state); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -2,19 +2,29 @@ package aws
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
-	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/aws/ratelimit"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/tpriime/ec2diff/pkg"
 	"github.com/tpriime/ec2diff/pkg/logger"
 )
 
+// Maximum number of instance IDs requested at once by FetchByIDs.
+const idBatchSize = 200
+
 // awsFetcher fetches EC2 instances from AWS.
 type awsFetcher struct {
 	client    ec2API
 	pageLimit int32
+	filters   []types.Filter
+	ids       []string // Instance IDs the fetch is restricted to, if any
+	region    string
 }
 
 // ec2API defines the subset of EC2 client methods used.
@@ -28,41 +38,142 @@ type ec2API interface {
 }
 
 // NewAwsFetcher initializes an AWS EC2 client and returns a LiveFetcher.
-func NewAwsFetcher(ctx context.Context, pageLimit int32) (pkg.PaginatedLiveFetcher, error) {
-	cfg, err := config.LoadDefaultConfig(ctx)
+//
+// When several regions, profiles or roles are given, a client is created for each
+// combination and the returned LiveFetcher fans out across them concurrently.
+func NewAwsFetcher(ctx context.Context, pageLimit int32, opts ...Option) (pkg.PaginatedLiveFetcher, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	cfgs, err := loadConfigs(ctx, o)
 	if err != nil {
 		return nil, fmt.Errorf("unable to load AWS config: %w", err)
 	}
 
-	return &awsFetcher{client: ec2.NewFromConfig(cfg), pageLimit: pageLimit}, nil
+	fetchers := make([]pkg.PaginatedLiveFetcher, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		client := ec2.NewFromConfig(cfg, func(eo *ec2.Options) {
+			if o.rateLimit > 0 {
+				eo.HTTPClient = newRateLimitedClient(eo.HTTPClient, o.rateLimit)
+			}
+		})
+		fetchers = append(fetchers, &awsFetcher{
+			client:    client,
+			pageLimit: pageLimit,
+			filters:   o.filters,
+			ids:       o.instanceIDs,
+			region:    cfg.Region,
+		})
+	}
+
+	if len(fetchers) == 1 {
+		return fetchers[0], nil
+	}
+	return &multiFetcher{fetchers: fetchers}, nil
 }
 
 // Fetch retrieves all EC2 instances from AWS in a paginated manner and maps them by instance ID.
-// - onPageFn declares function to run per pagination
+// - onPageFn declares function to run per pagination. Returning false stops fetching further pages.
+// When restricted to instance IDs, they are fetched in batches as by FetchByIDs.
 func (f *awsFetcher) Fetch(ctx context.Context, onPageFn func(page int, instances pkg.InstanceMap) bool) error {
-	paginator := ec2.NewDescribeInstancesPaginator(f.client, &ec2.DescribeInstancesInput{MaxResults: &f.pageLimit})
+	if len(f.ids) > 0 {
+		return f.fetchByIDs(ctx, f.ids, onPageFn)
+	}
 	pageCount := 1
+	_, err := f.fetch(ctx, f.filters, &pageCount, onPageFn)
+	return err
+}
+
+// FetchByIDs retrieves only the given instances, splitting the IDs into batches across requests.
+//
+// IDs are passed through the instance-id filter rather than the InstanceIds parameter, which
+// rejects the whole request if any instance no longer exists and cannot be paginated.
+// Page numbers keep increasing across batches. A batch with a failed page is given up,
+// and its page errors are returned once the other batches are fetched. If the fetcher is
+// restricted to instance IDs, only the IDs in both lists are fetched.
+func (f *awsFetcher) FetchByIDs(ctx context.Context, ids []string, onPageFn func(page int, instances pkg.InstanceMap) bool) error {
+	if len(f.ids) > 0 {
+		ids = slices.DeleteFunc(slices.Clone(ids), func(id string) bool { return !slices.Contains(f.ids, id) })
+	}
+	return f.fetchByIDs(ctx, ids, onPageFn)
+}
+
+// fetchByIDs fetches the given instances, in batches of idBatchSize.
+func (f *awsFetcher) fetchByIDs(ctx context.Context, ids []string, onPageFn func(page int, instances pkg.InstanceMap) bool) error {
+	pageCount := 1
+	name := "instance-id"
+	var pageErrs []error
+	for batch := range slices.Chunk(ids, idBatchSize) {
+		filters := append(slices.Clone(f.filters), types.Filter{Name: &name, Values: batch})
+		more, err := f.fetch(ctx, filters, &pageCount, onPageFn)
+		var pageErr *pkg.PageError
+		if errors.As(err, &pageErr) {
+			pageErrs = append(pageErrs, err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !more {
+			break
+		}
+	}
+	return errors.Join(pageErrs...)
+}
+
+// fetch pages through DescribeInstances with the given filters, numbering pages from pageCount.
+// It reports whether fetching should go on, which is false once onPageFn asks to stop.
+// A page still failing after retries ends the fetch with a pkg.PageError, as the pages
+// after it cannot be requested without its NextToken. Errors that are not retried, such
+// as invalid filters or denied access, fail the fetch as a whole.
+func (f *awsFetcher) fetch(ctx context.Context, filters []types.Filter, pageCount *int, onPageFn func(page int, instances pkg.InstanceMap) bool) (bool, error) {
+	paginator := ec2.NewDescribeInstancesPaginator(f.client, &ec2.DescribeInstancesInput{
+		MaxResults: &f.pageLimit,
+		Filters:    filters,
+	})
 
 	for paginator.HasMorePages() {
 		logger.Info(ctx, "Fetching next batch of aws instances...", "op", "awsFetcher.Fetch")
 		page, err := paginator.NextPage(ctx)
 		if err != nil {
-			return fmt.Errorf("failed to fetch page %d: %w", pageCount, err)
+			if ctx.Err() != nil || !retriesExhausted(err) {
+				return false, fmt.Errorf("failed to fetch page %d: %w", *pageCount, err)
+			}
+			pageErr := &pkg.PageError{Page: *pageCount, Region: f.region, Err: err}
+			*pageCount++
+			return false, pageErr
 		}
 
 		instances := make(pkg.InstanceMap)
 		for _, reservation := range page.Reservations {
 			for _, instance := range reservation.Instances {
 				// Convert AWS instance to local model and store
-				instances[*instance.InstanceId] = toModel(instance)
+				inst := toModel(instance)
+				inst.Account = valstr(reservation.OwnerId)
+				inst.Region = f.region
+				instances[inst.ID] = inst
 			}
 		}
 
-		onPageFn(pageCount, instances)
-		pageCount++
+		more := onPageFn(*pageCount, instances)
+		*pageCount++
+		if !more {
+			logger.Info(ctx, "Stopped fetching early", "op", "awsFetcher.Fetch")
+			return false, nil
+		}
 	}
 
-	return nil
+	return true, nil
+}
+
+// retriesExhausted reports whether err is a retryable error that the retryer gave up on,
+// either after its last attempt or because its retry quota ran out.
+func retriesExhausted(err error) bool {
+	var maxAttempts *retry.MaxAttemptsError
+	var quota ratelimit.QuotaExceededError
+	return errors.As(err, &maxAttempts) || errors.As(err, &quota)
 }
 
 // toModel maps an AWS EC2 instance to the local pkg.Instance type.
@@ -78,21 +189,55 @@ func toModel(inst types.Instance) pkg.Instance {
 	}
 
 	sgs := []string{}
+	sgIDs := []string{}
 	for _, sg := range inst.SecurityGroups {
 		sgs = append(sgs, valstr(sg.GroupName))
+		sgIDs = append(sgIDs, valstr(sg.GroupId))
+	}
+
+	var az string
+	if inst.Placement != nil {
+		az = valstr(inst.Placement.AvailabilityZone)
+	}
+
+	var monitoring bool
+	if inst.Monitoring != nil {
+		monitoring = inst.Monitoring.State == types.MonitoringStateEnabled
+	}
+
+	var profile string
+	if inst.IamInstanceProfile != nil {
+		profile = profileName(valstr(inst.IamInstanceProfile.Arn))
 	}
 
 	return pkg.Instance{
-		ID:             valstr(inst.InstanceId),
-		Type:           string(inst.InstanceType),
-		State:          state,
-		KeyName:        valstr(inst.KeyName),
-		Tags:           tags,
-		SecurityGroups: sgs,
-		PublicIP:       valstr(inst.PublicIpAddress),
+		ID:                  valstr(inst.InstanceId),
+		Type:                string(inst.InstanceType),
+		State:               state,
+		KeyName:             valstr(inst.KeyName),
+		Tags:                tags,
+		SecurityGroups:      sgs,
+		PublicIP:            valstr(inst.PublicIpAddress),
+		PrivateIP:           valstr(inst.PrivateIpAddress),
+		Ami:                 valstr(inst.ImageId),
+		AvailabilityZone:    az,
+		SubnetID:            valstr(inst.SubnetId),
+		VpcID:               valstr(inst.VpcId),
+		VpcSecurityGroupIDs: sgIDs,
+		Monitoring:          monitoring,
+		Architecture:        string(inst.Architecture),
+		VirtualizationType:  string(inst.VirtualizationType),
+		IamInstanceProfile:  profile,
 	}
 }
 
+// profileName extracts the instance profile name from its ARN,
+// e.g. arn:aws:iam::123456789012:instance-profile/path/name -> name.
+// Terraform state records the name, while EC2 only returns the ARN.
+func profileName(arn string) string {
+	return arn[strings.LastIndex(arn, "/")+1:]
+}
+
 // valstr safely dereferences a *string to a string.
 func valstr(ptr *string) (s string) {
 	if ptr != nil {
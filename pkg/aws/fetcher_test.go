@@ -3,18 +3,26 @@ package aws
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
 	"testing"
 
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/tpriime/ec2diff/pkg"
+	"github.com/tpriime/ec2diff/pkg/fakeec2"
 )
 
 type MockEC2API struct {
 	mockReponseJson string
+	inputs          []*ec2.DescribeInstancesInput
 }
 
-func (m *MockEC2API) DescribeInstances(ctx context.Context, _ *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+func (m *MockEC2API) DescribeInstances(ctx context.Context, input *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	m.inputs = append(m.inputs, input)
 	data := []byte(m.mockReponseJson)
 
 	var output ec2.DescribeInstancesOutput
@@ -93,3 +101,199 @@ func TestGetInstance_EmptyResponse(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, result, 0)
 }
+
+func TestToModel_ExtendedAttributes(t *testing.T) {
+	fetcher := NewMockAwsFetcher(`
+{
+  "Reservations": [
+    {
+      "Instances": [
+        {
+          "InstanceId": "i-0123456789abcdef0",
+          "ImageId": "ami-0abc",
+          "SubnetId": "subnet-0abc",
+          "VpcId": "vpc-0abc",
+          "PrivateIpAddress": "10.0.0.10",
+          "Architecture": "x86_64",
+          "VirtualizationType": "hvm",
+          "Placement": { "AvailabilityZone": "us-east-1a" },
+          "Monitoring": { "State": "enabled" },
+          "IamInstanceProfile": { "Arn": "arn:aws:iam::123456789012:instance-profile/app/my-profile" },
+          "SecurityGroups": [
+            { "GroupId": "sg-0123456789abcdef0", "GroupName": "default" }
+          ]
+        }
+      ]
+    }
+  ]
+}`)
+
+	result := pkg.InstanceMap{}
+	err := fetcher.Fetch(t.Context(), func(page int, instances pkg.InstanceMap) bool {
+		for v := range instances {
+			result[v] = instances[v]
+		}
+		return true
+	})
+
+	assert.NoError(t, err)
+	inst := result["i-0123456789abcdef0"]
+	assert.Equal(t, "ami-0abc", inst.Ami)
+	assert.Equal(t, "subnet-0abc", inst.SubnetID)
+	assert.Equal(t, "vpc-0abc", inst.VpcID)
+	assert.Equal(t, "10.0.0.10", inst.PrivateIP)
+	assert.Equal(t, "x86_64", inst.Architecture)
+	assert.Equal(t, "hvm", inst.VirtualizationType)
+	assert.Equal(t, "us-east-1a", inst.AvailabilityZone)
+	assert.True(t, inst.Monitoring)
+	assert.Equal(t, "my-profile", inst.IamInstanceProfile)
+	assert.Equal(t, []string{"sg-0123456789abcdef0"}, inst.VpcSecurityGroupIDs)
+}
+
+func TestFetch_Filters(t *testing.T) {
+	api := &MockEC2API{mockReponseJson: `{"Reservations": []}`}
+	o := &options{}
+	WithFilters(Filter{Name: "tag:Env", Values: []string{"prod"}})(o)
+	WithInstanceIDs("i-1", "i-2")(o)
+	WithInstanceIDs()(o)
+	fetcher := &awsFetcher{client: api, pageLimit: 10, filters: o.filters, ids: o.instanceIDs}
+
+	err := fetcher.Fetch(t.Context(), func(page int, instances pkg.InstanceMap) bool { return true })
+
+	assert.NoError(t, err)
+	assert.Len(t, api.inputs, 1)
+	filters := api.inputs[0].Filters
+	assert.Len(t, filters, 2)
+	assert.Equal(t, "tag:Env", *filters[0].Name)
+	assert.Equal(t, []string{"prod"}, filters[0].Values)
+	assert.Equal(t, "instance-id", *filters[1].Name)
+	assert.Equal(t, []string{"i-1", "i-2"}, filters[1].Values)
+}
+
+func TestFetchByIDs_Batches(t *testing.T) {
+	api := &MockEC2API{mockReponseJson: `{"Reservations": [{"Instances": [{"InstanceId": "i-1"}]}]}`}
+	o := &options{}
+	WithFilters(Filter{Name: "tag:Env", Values: []string{"prod"}})(o)
+	fetcher := &awsFetcher{client: api, pageLimit: 10, filters: o.filters}
+
+	ids := make([]string, idBatchSize+1)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("i-%d", i)
+	}
+
+	pages := []int{}
+	err := fetcher.FetchByIDs(t.Context(), ids, func(page int, instances pkg.InstanceMap) bool {
+		pages = append(pages, page)
+		return true
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, pages, "page numbers should continue across batches")
+	assert.Len(t, api.inputs, 2)
+	assert.Len(t, api.inputs[0].Filters, 2, "user filters should be kept")
+	assert.Len(t, api.inputs[0].Filters[1].Values, idBatchSize)
+	assert.Equal(t, []string{ids[idBatchSize]}, api.inputs[1].Filters[1].Values)
+	assert.Len(t, fetcher.filters, 1, "fetcher filters should not be mutated")
+}
+
+func TestFetchByIDs_IntersectsInstanceIDs(t *testing.T) {
+	api := &MockEC2API{mockReponseJson: `{"Reservations": []}`}
+	fetcher := &awsFetcher{client: api, pageLimit: 10, ids: []string{"i-1", "i-2"}}
+
+	err := fetcher.FetchByIDs(t.Context(), []string{"i-2", "i-3"}, func(int, pkg.InstanceMap) bool { return true })
+
+	assert.NoError(t, err)
+	assert.Len(t, api.inputs, 1)
+	assert.Len(t, api.inputs[0].Filters, 1, "only one instance-id filter should be sent")
+	assert.Equal(t, []string{"i-2"}, api.inputs[0].Filters[0].Values)
+}
+
+func TestFetch_TagsAccountAndRegion(t *testing.T) {
+	api := &MockEC2API{mockReponseJson: `{"Reservations": [{"OwnerId": "123456789012", "Instances": [{"InstanceId": "i-1"}]}]}`}
+	fetcher := &awsFetcher{client: api, pageLimit: 10, region: "eu-west-1"}
+
+	result := pkg.InstanceMap{}
+	err := fetcher.Fetch(t.Context(), func(page int, instances pkg.InstanceMap) bool {
+		for v := range instances {
+			result[v] = instances[v]
+		}
+		return true
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "123456789012", result["i-1"].Account)
+	assert.Equal(t, "eu-west-1", result["i-1"].Region)
+}
+
+func TestFetch_StopsWhenOnPageReturnsFalse(t *testing.T) {
+	api := &MockEC2API{mockReponseJson: `{"NextToken": "more", "Reservations": [{"Instances": [{"InstanceId": "i-1"}]}]}`}
+	fetcher := &awsFetcher{client: api, pageLimit: 10}
+
+	pages := 0
+	err := fetcher.Fetch(t.Context(), func(page int, instances pkg.InstanceMap) bool {
+		pages++
+		return page < 2
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, pages)
+	assert.Len(t, api.inputs, 2, "no page should be requested after stopping")
+}
+
+func TestFetchByIDs_StopsAcrossBatches(t *testing.T) {
+	api := &MockEC2API{mockReponseJson: `{"Reservations": [{"Instances": [{"InstanceId": "i-1"}]}]}`}
+	fetcher := &awsFetcher{client: api, pageLimit: 10}
+
+	err := fetcher.FetchByIDs(t.Context(), make([]string, idBatchSize*3), func(page int, instances pkg.InstanceMap) bool {
+		return false
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, api.inputs, 1, "later batches should not be requested after stopping")
+}
+
+func TestNewAwsFetcher_Endpoint(t *testing.T) {
+	server := fakeec2.NewServer()
+	t.Cleanup(server.Close)
+	server.SetEnv(t)
+
+	for i := range 12 {
+		id := fmt.Sprintf("i-%02d", i)
+		env := "prod"
+		if i%2 == 1 {
+			env = "staging"
+		}
+		server.AddInstances(types.Instance{
+			InstanceId:   &id,
+			InstanceType: types.InstanceTypeT3Micro,
+			Tags:         []types.Tag{{Key: awssdk.String("Env"), Value: &env}},
+		})
+	}
+
+	fetcher, err := NewAwsFetcher(t.Context(), 5, WithFilters(Filter{Name: "tag:Env", Values: []string{"prod"}}))
+	assert.NoError(t, err)
+
+	result := pkg.InstanceMap{}
+	pages := 0
+	err = fetcher.Fetch(t.Context(), func(page int, instances pkg.InstanceMap) bool {
+		pages = page
+		maps.Copy(result, instances)
+		return true
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, pages)
+	assert.Len(t, result, 6)
+	assert.Equal(t, "t3.micro", result["i-00"].Type)
+	assert.Equal(t, fakeec2.DefaultOwnerID, result["i-00"].Account)
+	assert.Equal(t, "us-east-1", result["i-00"].Region)
+
+	result = pkg.InstanceMap{}
+	err = fetcher.(pkg.IDLiveFetcher).FetchByIDs(t.Context(), []string{"i-02", "i-03", "i-gone"}, func(page int, instances pkg.InstanceMap) bool {
+		maps.Copy(result, instances)
+		return true
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"i-02"}, slices.Collect(maps.Keys(result)), "unknown IDs and filtered out instances should be skipped")
+}
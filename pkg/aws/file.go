@@ -0,0 +1,115 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/tpriime/ec2diff/pkg"
+	"github.com/tpriime/ec2diff/pkg/logger"
+)
+
+// fileFetcher serves instances from a saved DescribeInstances response instead of calling AWS.
+type fileFetcher struct {
+	path string
+	ids  []string
+}
+
+// NewFileFetcher returns a LiveFetcher reading the output of `aws ec2 describe-instances` saved at path.
+//
+// The file may hold a single response, several responses one after another, or a JSON
+// array of them, as saved when paging manually with --starting-token. Each response is
+// served as a page. Of the options, only the instance-id filter applies; other filters
+// can only be evaluated by AWS.
+func NewFileFetcher(path string, opts ...Option) (pkg.PaginatedLiveFetcher, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	f := &fileFetcher{path: path, ids: o.instanceIDs}
+	for _, filter := range o.filters {
+		if valstr(filter.Name) != "instance-id" {
+			return nil, fmt.Errorf("filter %s is not supported with a saved response", valstr(filter.Name))
+		}
+		f.ids = append(f.ids, filter.Values...)
+	}
+	return f, nil
+}
+
+// Fetch serves every instance in the file, a page per saved response.
+func (f *fileFetcher) Fetch(ctx context.Context, onPageFn func(page int, instances pkg.InstanceMap) bool) error {
+	return f.fetch(ctx, f.ids, onPageFn)
+}
+
+// FetchByIDs serves only the given instances from the file.
+func (f *fileFetcher) FetchByIDs(ctx context.Context, ids []string, onPageFn func(page int, instances pkg.InstanceMap) bool) error {
+	return f.fetch(ctx, ids, onPageFn)
+}
+
+// fetch decodes the saved responses, keeping only the given IDs if any.
+func (f *fileFetcher) fetch(ctx context.Context, ids []string, onPageFn func(page int, instances pkg.InstanceMap) bool) error {
+	pages, err := readPages(f.path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", f.path, err)
+	}
+	logger.Info(ctx, fmt.Sprintf("Read %d pages of instances", len(pages)), "op", "fileFetcher.Fetch", "file", f.path)
+
+	for i, page := range pages {
+		instances := make(pkg.InstanceMap)
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				inst := toModel(instance)
+				if len(ids) > 0 && !slices.Contains(ids, inst.ID) {
+					continue
+				}
+				inst.Account = valstr(reservation.OwnerId)
+				instances[inst.ID] = inst
+			}
+		}
+
+		if !onPageFn(i+1, instances) {
+			logger.Info(ctx, "Stopped fetching early", "op", "fileFetcher.Fetch")
+			return nil
+		}
+	}
+	return nil
+}
+
+// readPages decodes a sequence or an array of DescribeInstances responses.
+// The SDK types decode the CLI output directly, as it uses the same field names.
+func readPages(path string) ([]ec2.DescribeInstancesOutput, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var pages []ec2.DescribeInstancesOutput
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &pages); err != nil {
+			return nil, err
+		}
+		return pages, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var page ec2.DescribeInstancesOutput
+		if err := dec.Decode(&page); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		pages = append(pages, page)
+	}
+	if len(pages) == 0 {
+		return nil, errors.New("no DescribeInstances response found")
+	}
+	return pages, nil
+}
@@ -0,0 +1,132 @@
+package aws
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tpriime/ec2diff/pkg"
+)
+
+const pageA = `{"Reservations": [{"OwnerId": "111111111111", "Instances": [{"InstanceId": "i-a", "InstanceType": "t3.micro"}]}]}`
+const pageB = `{"Reservations": [{"OwnerId": "222222222222", "Instances": [{"InstanceId": "i-b", "InstanceType": "t3.large"}]}], "NextToken": null}`
+
+func writeFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "instances.json")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+// collect fetches every page, returning the instances and the page numbers seen.
+func collect(t *testing.T, fetch func(onPageFn func(int, pkg.InstanceMap) bool) error) (pkg.InstanceMap, []int) {
+	result := pkg.InstanceMap{}
+	pages := []int{}
+	err := fetch(func(page int, instances pkg.InstanceMap) bool {
+		pages = append(pages, page)
+		for id, inst := range instances {
+			result[id] = inst
+		}
+		return true
+	})
+	assert.NoError(t, err)
+	return result, pages
+}
+
+func TestFileFetcher_SingleResponse(t *testing.T) {
+	fetcher, err := NewFileFetcher("../../examples/resources/aws_ec2_response_full.json")
+	assert.NoError(t, err)
+
+	result, pages := collect(t, func(fn func(int, pkg.InstanceMap) bool) error {
+		return fetcher.Fetch(t.Context(), fn)
+	})
+
+	assert.Equal(t, []int{1}, pages)
+	assert.Contains(t, result, "i-0f3d4bc78c78cc67b")
+	assert.Equal(t, "534189516062", result["i-0f3d4bc78c78cc67b"].Account)
+}
+
+func TestFileFetcher_Pages(t *testing.T) {
+	tests := map[string]string{
+		"sequence": pageA + "\n" + pageB,
+		"array":    "[" + pageA + "," + pageB + "]",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			fetcher, err := NewFileFetcher(writeFile(t, content))
+			assert.NoError(t, err)
+
+			result, pages := collect(t, func(fn func(int, pkg.InstanceMap) bool) error {
+				return fetcher.Fetch(t.Context(), fn)
+			})
+
+			assert.Equal(t, []int{1, 2}, pages)
+			assert.Equal(t, "t3.micro", result["i-a"].Type)
+			assert.Equal(t, "111111111111", result["i-a"].Account)
+			assert.Equal(t, "t3.large", result["i-b"].Type)
+			assert.Equal(t, "222222222222", result["i-b"].Account)
+		})
+	}
+}
+
+func TestFileFetcher_InstanceIDs(t *testing.T) {
+	path := writeFile(t, pageA+pageB)
+
+	fetcher, err := NewFileFetcher(path, WithInstanceIDs("i-b"))
+	assert.NoError(t, err)
+	result, _ := collect(t, func(fn func(int, pkg.InstanceMap) bool) error {
+		return fetcher.Fetch(t.Context(), fn)
+	})
+	assert.Equal(t, []string{"i-b"}, keys(result))
+
+	fetcher, err = NewFileFetcher(path)
+	assert.NoError(t, err)
+	result, _ = collect(t, func(fn func(int, pkg.InstanceMap) bool) error {
+		return fetcher.(pkg.IDLiveFetcher).FetchByIDs(t.Context(), []string{"i-a"}, fn)
+	})
+	assert.Equal(t, []string{"i-a"}, keys(result))
+}
+
+func TestFileFetcher_UnsupportedFilter(t *testing.T) {
+	_, err := NewFileFetcher("instances.json", WithFilters(Filter{Name: "tag:Env", Values: []string{"prod"}}))
+	assert.ErrorContains(t, err, "filter tag:Env is not supported")
+}
+
+func TestFileFetcher_StopEarly(t *testing.T) {
+	fetcher, err := NewFileFetcher(writeFile(t, pageA+pageB))
+	assert.NoError(t, err)
+
+	calls := 0
+	err = fetcher.Fetch(t.Context(), func(page int, instances pkg.InstanceMap) bool {
+		calls++
+		return false
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestFileFetcher_InvalidFile(t *testing.T) {
+	tests := map[string]string{
+		"malformed": `{"Reservations": [`,
+		"empty":     "  \n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			fetcher, err := NewFileFetcher(writeFile(t, content))
+			assert.NoError(t, err)
+
+			err = fetcher.Fetch(t.Context(), func(int, pkg.InstanceMap) bool { return true })
+			assert.ErrorContains(t, err, "failed to read")
+		})
+	}
+}
+
+func keys(m pkg.InstanceMap) []string {
+	out := []string{}
+	for id := range m {
+		out = append(out, id)
+	}
+	return out
+}
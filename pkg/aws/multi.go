@@ -0,0 +1,234 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/tpriime/ec2diff/pkg"
+	"github.com/tpriime/ec2diff/pkg/logger"
+)
+
+// AllRegions selects every region enabled for an account.
+const AllRegions = "all"
+
+// Region used to list the enabled regions when none is configured.
+const fallbackRegion = "us-east-1"
+
+// regionsAPI defines the EC2 client method used to list regions.
+type regionsAPI interface {
+	DescribeRegions(
+		ctx context.Context,
+		params *ec2.DescribeRegionsInput,
+		optFns ...func(*ec2.Options),
+	) (*ec2.DescribeRegionsOutput, error)
+}
+
+// multiFetcher fans out fetches across several account and region fetchers concurrently.
+type multiFetcher struct {
+	fetchers []pkg.PaginatedLiveFetcher
+}
+
+// Fetch retrieves instances from every fetcher concurrently.
+// Pages are handed to onPageFn one at a time, numbered in the order they arrive.
+// Once onPageFn returns false, every fetcher stops and later pages are dropped.
+func (m *multiFetcher) Fetch(ctx context.Context, onPageFn func(page int, instances pkg.InstanceMap) bool) error {
+	return m.fanOut(ctx, onPageFn, func(f pkg.PaginatedLiveFetcher, onPage func(int, pkg.InstanceMap) bool) error {
+		return f.Fetch(ctx, onPage)
+	})
+}
+
+// FetchByIDs retrieves the given instances from every fetcher concurrently.
+func (m *multiFetcher) FetchByIDs(ctx context.Context, ids []string, onPageFn func(page int, instances pkg.InstanceMap) bool) error {
+	return m.fanOut(ctx, onPageFn, func(f pkg.PaginatedLiveFetcher, onPage func(int, pkg.InstanceMap) bool) error {
+		if idFetcher, ok := f.(pkg.IDLiveFetcher); ok {
+			return idFetcher.FetchByIDs(ctx, ids, onPage)
+		}
+		return f.Fetch(ctx, onPage)
+	})
+}
+
+// fanOut runs fetch for every fetcher concurrently, serializing calls to onPageFn.
+func (m *multiFetcher) fanOut(
+	ctx context.Context,
+	onPageFn func(page int, instances pkg.InstanceMap) bool,
+	fetch func(f pkg.PaginatedLiveFetcher, onPage func(int, pkg.InstanceMap) bool) error,
+) error {
+	var mu sync.Mutex
+	pageCount := 0
+	stopped := false
+	onPage := func(_ int, instances pkg.InstanceMap) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if stopped {
+			return false
+		}
+		pageCount++
+		stopped = !onPageFn(pageCount, instances)
+		return !stopped
+	}
+
+	errs := make([]error, len(m.fetchers))
+	var wg sync.WaitGroup
+	for i, f := range m.fetchers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = fetch(f, onPage)
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// account is an AWS config for one account, keyed by what identifies the account.
+type account struct {
+	key string
+	cfg awssdk.Config
+}
+
+// loadConfigs returns an AWS config for every combination of account and region to fetch from.
+//
+// Accounts are given by shared config profiles, or the default credential chain, or by
+// the roles assumed with them. Without regions, each account's configured region is used.
+// Combinations repeating an account and region are only fetched from once.
+func loadConfigs(ctx context.Context, o *options) ([]awssdk.Config, error) {
+	accounts, err := loadAccounts(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfgs []awssdk.Config
+	seen := map[[2]string]struct{}{}
+	for _, acc := range accounts {
+		regions := o.regions
+		if len(regions) == 0 {
+			regions = []string{acc.cfg.Region}
+		} else if slices.Contains(regions, AllRegions) {
+			if acc.cfg.Region == "" {
+				acc.cfg.Region = fallbackRegion
+			}
+			if regions, err = listRegions(ctx, ec2.NewFromConfig(acc.cfg)); err != nil {
+				return nil, err
+			}
+		}
+		for _, region := range regions {
+			if _, ok := seen[[2]string{acc.key, region}]; ok {
+				continue
+			}
+			seen[[2]string{acc.key, region}] = struct{}{}
+			cfg := acc.cfg.Copy()
+			cfg.Region = region
+			cfgs = append(cfgs, cfg)
+		}
+	}
+	return cfgs, nil
+}
+
+// loadAccounts returns a config for each account to fetch from.
+//
+// Without roles, each profile is an account. Otherwise each role is an account, assumed
+// once with the profile at the same position, or with the only profile given. As a role
+// ARN fixes its account, roles in an account already listed are skipped.
+func loadAccounts(ctx context.Context, o *options) ([]account, error) {
+	profiles := o.profiles
+	if len(profiles) == 0 {
+		profiles = []string{""}
+	}
+	if len(o.roles) > 0 && len(profiles) > 1 && len(profiles) != len(o.roles) {
+		return nil, fmt.Errorf("got %d profiles for %d roles, give one profile or one per role", len(profiles), len(o.roles))
+	}
+
+	bases := map[string]awssdk.Config{}
+	base := func(profile string) (awssdk.Config, error) {
+		if cfg, ok := bases[profile]; ok {
+			return cfg, nil
+		}
+		var loadOpts []func(*config.LoadOptions) error
+		if profile != "" {
+			loadOpts = append(loadOpts, config.WithSharedConfigProfile(profile))
+		}
+		cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
+		if err != nil {
+			return cfg, err
+		}
+		if err := configureCalls(&cfg, o); err != nil {
+			return cfg, err
+		}
+		bases[profile] = cfg
+		return cfg, nil
+	}
+
+	var accounts []account
+	seen := map[string]struct{}{}
+	if len(o.roles) == 0 {
+		for _, profile := range profiles {
+			if _, ok := seen[profile]; ok {
+				continue
+			}
+			seen[profile] = struct{}{}
+			cfg, err := base(profile)
+			if err != nil {
+				return nil, err
+			}
+			accounts = append(accounts, account{key: "profile:" + profile, cfg: cfg})
+		}
+		return accounts, nil
+	}
+
+	for i, role := range o.roles {
+		key := roleAccount(role)
+		if _, ok := seen[key]; ok {
+			logger.Warn(ctx, "Skipping role in an account already fetched from", "role", role)
+			continue
+		}
+		seen[key] = struct{}{}
+
+		profile := profiles[0]
+		if len(profiles) > 1 {
+			profile = profiles[i]
+		}
+		cfg, err := base(profile)
+		if err != nil {
+			return nil, err
+		}
+		assumed := cfg.Copy()
+		assumed.Credentials = awssdk.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), role))
+		accounts = append(accounts, account{key: key, cfg: assumed})
+	}
+	return accounts, nil
+}
+
+// roleAccount returns the account ID of a role ARN, e.g. arn:aws:iam::111111111111:role/audit
+// -> 111111111111. ARNs it cannot parse are returned whole.
+func roleAccount(arn string) string {
+	parts := strings.Split(arn, ":")
+	if len(parts) < 6 || parts[4] == "" {
+		return arn
+	}
+	return parts[4]
+}
+
+// listRegions returns the names of the regions enabled for the account.
+func listRegions(ctx context.Context, client regionsAPI) ([]string, error) {
+	out, err := client.DescribeRegions(ctx, &ec2.DescribeRegionsInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list regions: %w", err)
+	}
+
+	regions := make([]string, 0, len(out.Regions))
+	for _, r := range out.Regions {
+		regions = append(regions, valstr(r.RegionName))
+	}
+	logger.Info(ctx, fmt.Sprintf("Found %d enabled regions", len(regions)), "op", "aws.listRegions")
+	return regions, nil
+}
@@ -0,0 +1,147 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/tpriime/ec2diff/pkg"
+	"github.com/tpriime/ec2diff/pkg/mocks"
+)
+
+type MockRegionsAPI struct {
+	regions []string
+	err     error
+}
+
+func (m *MockRegionsAPI) DescribeRegions(ctx context.Context, _ *ec2.DescribeRegionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeRegionsOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	out := &ec2.DescribeRegionsOutput{}
+	for _, r := range m.regions {
+		out.Regions = append(out.Regions, types.Region{RegionName: &r})
+	}
+	return out, nil
+}
+
+func TestMultiFetcher_Fetch(t *testing.T) {
+	fetcher := &multiFetcher{fetchers: []pkg.PaginatedLiveFetcher{
+		&mocks.MockLiveFetcher{Instances: pkg.InstanceMap{"i-1": {ID: "i-1", Region: "us-east-1"}}},
+		&mocks.MockLiveFetcher{Instances: pkg.InstanceMap{"i-2": {ID: "i-2", Region: "eu-west-1"}}},
+		&mocks.MockLiveFetcher{Instances: pkg.InstanceMap{"i-3": {ID: "i-3", Region: "ap-south-1"}}},
+	}}
+
+	result := pkg.InstanceMap{}
+	pages := []int{}
+	err := fetcher.Fetch(t.Context(), func(page int, instances pkg.InstanceMap) bool {
+		pages = append(pages, page)
+		for v := range instances {
+			result[v] = instances[v]
+		}
+		return true
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 3)
+	assert.ElementsMatch(t, []int{1, 2, 3}, pages)
+	assert.Equal(t, "eu-west-1", result["i-2"].Region)
+}
+
+func TestMultiFetcher_FetchByIDs(t *testing.T) {
+	a := &mocks.MockLiveFetcher{Instances: pkg.InstanceMap{"i-1": {ID: "i-1"}}}
+	b := &mocks.MockLiveFetcher{Instances: pkg.InstanceMap{"i-2": {ID: "i-2"}}}
+	fetcher := &multiFetcher{fetchers: []pkg.PaginatedLiveFetcher{a, b}}
+
+	result := pkg.InstanceMap{}
+	err := fetcher.FetchByIDs(t.Context(), []string{"i-2"}, func(page int, instances pkg.InstanceMap) bool {
+		for v := range instances {
+			result[v] = instances[v]
+		}
+		return true
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"i-2"}, a.FetchedIDs)
+	assert.Equal(t, []string{"i-2"}, b.FetchedIDs)
+	assert.Len(t, result, 1)
+}
+
+func TestMultiFetcher_Errors(t *testing.T) {
+	fetcher := &multiFetcher{fetchers: []pkg.PaginatedLiveFetcher{
+		&mocks.MockLiveFetcher{Instances: pkg.InstanceMap{"i-1": {ID: "i-1"}}},
+		&mocks.MockLiveFetcher{Err: errors.New("access denied")},
+	}}
+
+	err := fetcher.Fetch(t.Context(), func(page int, instances pkg.InstanceMap) bool { return true })
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "access denied")
+}
+
+func TestListRegions(t *testing.T) {
+	regions, err := listRegions(t.Context(), &MockRegionsAPI{regions: []string{"us-east-1", "eu-west-1"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"us-east-1", "eu-west-1"}, regions)
+
+	_, err = listRegions(t.Context(), &MockRegionsAPI{err: errors.New("unauthorized")})
+	assert.Error(t, err)
+}
+
+func TestLoadConfigs(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+
+	cfgs, err := loadConfigs(t.Context(), &options{
+		regions: []string{"us-east-1", "eu-west-1"},
+		roles:   []string{"arn:aws:iam::111111111111:role/a", "arn:aws:iam::222222222222:role/b"},
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, cfgs, 4, "expected one config per role and region")
+	assert.Equal(t, "us-east-1", cfgs[0].Region)
+	assert.Equal(t, "eu-west-1", cfgs[1].Region)
+}
+
+func TestLoadConfigs_AssumesEachRoleOnce(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+
+	cfgs, err := loadConfigs(t.Context(), &options{
+		regions: []string{"us-east-1", "us-east-1"},
+		roles:   []string{"arn:aws:iam::111111111111:role/audit", "arn:aws:iam::111111111111:role/admin"},
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, cfgs, 1, "an account and region should be fetched from once")
+
+	_, err = loadConfigs(t.Context(), &options{
+		profiles: []string{"dev", "prod"},
+		roles:    []string{"arn:aws:iam::111111111111:role/audit"},
+	})
+	assert.ErrorContains(t, err, "got 2 profiles for 1 roles")
+}
+
+func TestMultiFetcher_Stops(t *testing.T) {
+	pages := []pkg.InstanceMap{{"i-1": {ID: "i-1"}}, {"i-2": {ID: "i-2"}}, {"i-3": {ID: "i-3"}}}
+	a := &mocks.MockLiveFetcher{Pages: pages}
+	b := &mocks.MockLiveFetcher{Pages: pages}
+	fetcher := &multiFetcher{fetchers: []pkg.PaginatedLiveFetcher{a, b}}
+
+	calls := 0
+	err := fetcher.Fetch(t.Context(), func(page int, instances pkg.InstanceMap) bool {
+		calls++
+		return false
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls, "pages arriving after a stop should be dropped")
+	assert.Equal(t, 1, a.Served)
+	assert.Equal(t, 1, b.Served)
+}
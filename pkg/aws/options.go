@@ -0,0 +1,100 @@
+package aws
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// options holds the optional settings of the AWS fetcher.
+type options struct {
+	filters     []types.Filter
+	instanceIDs []string
+	regions     []string
+	profiles    []string
+	roles       []string
+
+	retryMode   string
+	maxAttempts int
+	maxBackoff  time.Duration
+	rateLimit   float64
+	callTimeout time.Duration
+}
+
+// Option configures optional behaviour of the AWS fetcher.
+type Option func(*options)
+
+// Filter narrows down the instances returned by EC2, using its filter names
+// such as instance-state-name, vpc-id or tag:<key>.
+type Filter struct {
+	Name   string
+	Values []string
+}
+
+// WithFilters applies the given filters server-side on every DescribeInstances call.
+func WithFilters(filters ...Filter) Option {
+	return func(o *options) {
+		for _, filter := range filters {
+			o.filters = append(o.filters, types.Filter{Name: &filter.Name, Values: filter.Values})
+		}
+	}
+}
+
+// WithInstanceIDs restricts the fetch to the given instance IDs. They are sent in
+// batches, as FetchByIDs does, since EC2 limits the values of a filter.
+func WithInstanceIDs(ids ...string) Option {
+	return func(o *options) {
+		o.instanceIDs = append(o.instanceIDs, ids...)
+	}
+}
+
+// WithRegions fetches from each of the given regions instead of the configured one.
+// AllRegions expands to every region enabled for the account.
+func WithRegions(regions ...string) Option {
+	return func(o *options) {
+		o.regions = append(o.regions, regions...)
+	}
+}
+
+// WithProfiles fetches from the account of each of the given shared config profiles.
+func WithProfiles(profiles ...string) Option {
+	return func(o *options) {
+		o.profiles = append(o.profiles, profiles...)
+	}
+}
+
+// WithAssumeRoles fetches from the account of each of the given IAM role ARNs instead
+// of the profiles' own. Each role is assumed with the profile at the same position,
+// or with the only profile given.
+func WithAssumeRoles(roleARNs ...string) Option {
+	return func(o *options) {
+		o.roles = append(o.roles, roleARNs...)
+	}
+}
+
+// WithRetries sets how failed calls are retried. mode is the SDK retry mode, standard
+// or adaptive, the latter also slowing down requests once throttled. Retries back off
+// exponentially with jitter, up to maxBackoff. Zero values keep the AWS configuration,
+// e.g. AWS_RETRY_MODE and AWS_MAX_ATTEMPTS, or the SDK defaults.
+func WithRetries(mode string, maxAttempts int, maxBackoff time.Duration) Option {
+	return func(o *options) {
+		o.retryMode = mode
+		o.maxAttempts = maxAttempts
+		o.maxBackoff = maxBackoff
+	}
+}
+
+// WithRateLimit spaces out the requests to each account and region, retries included,
+// to at most rps per second. Zero means no limit.
+func WithRateLimit(rps float64) Option {
+	return func(o *options) {
+		o.rateLimit = rps
+	}
+}
+
+// WithCallTimeout bounds each attempt of a call, so a hung request is retried. Zero means no timeout.
+func WithCallTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.callTimeout = timeout
+	}
+}
@@ -0,0 +1,109 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+)
+
+// configureCalls applies the retry and timeout options to an AWS config.
+// Options left unset keep what the config was loaded with.
+func configureCalls(cfg *awssdk.Config, o *options) error {
+	mode := cfg.RetryMode
+	if o.retryMode != "" {
+		var err error
+		if mode, err = awssdk.ParseRetryMode(o.retryMode); err != nil {
+			return err
+		}
+	}
+	if o.maxAttempts > 0 {
+		cfg.RetryMaxAttempts = o.maxAttempts
+	}
+	cfg.RetryMode = mode
+	cfg.Retryer = newRetryer(mode, cfg.RetryMaxAttempts, o.maxBackoff)
+
+	if o.callTimeout > 0 {
+		client, ok := cfg.HTTPClient.(*awshttp.BuildableClient)
+		if !ok {
+			client = awshttp.NewBuildableClient()
+		}
+		cfg.HTTPClient = client.WithTimeout(o.callTimeout)
+	}
+	return nil
+}
+
+// newRetryer returns a retryer in the given mode, backing off exponentially with jitter.
+// Zero values use the SDK defaults: 3 attempts and a maximum backoff of 20 seconds.
+func newRetryer(mode awssdk.RetryMode, maxAttempts int, maxBackoff time.Duration) func() awssdk.Retryer {
+	standard := func(so *retry.StandardOptions) {
+		if maxAttempts > 0 {
+			so.MaxAttempts = maxAttempts
+		}
+		if maxBackoff > 0 {
+			so.MaxBackoff = maxBackoff
+			so.Backoff = retry.NewExponentialJitterBackoff(maxBackoff)
+		}
+	}
+
+	return func() awssdk.Retryer {
+		if mode == awssdk.RetryModeAdaptive {
+			return retry.NewAdaptiveMode(func(ao *retry.AdaptiveModeOptions) {
+				ao.StandardOptions = append(ao.StandardOptions, standard)
+			})
+		}
+		return retry.NewStandard(standard)
+	}
+}
+
+// rateLimitedClient spaces out the requests sent through an HTTP client to a fixed rate.
+// Each attempt of a call is a request, so retries are limited too.
+type rateLimitedClient struct {
+	client   awssdk.HTTPClient
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time // Earliest time the next request may be sent
+}
+
+// newRateLimitedClient limits the requests of client to rps per second.
+func newRateLimitedClient(client awssdk.HTTPClient, rps float64) *rateLimitedClient {
+	return &rateLimitedClient{client: client, interval: time.Duration(float64(time.Second) / rps)}
+}
+
+func (c *rateLimitedClient) Do(req *http.Request) (*http.Response, error) {
+	if err := c.wait(req.Context()); err != nil {
+		return nil, fmt.Errorf("rate limit: %w", err)
+	}
+	return c.client.Do(req)
+}
+
+// wait reserves the next free slot and blocks until it comes up or ctx is done.
+func (c *rateLimitedClient) wait(ctx context.Context) error {
+	c.mu.Lock()
+	now := time.Now()
+	at := c.next
+	if at.Before(now) {
+		at = now
+	}
+	c.next = at.Add(c.interval)
+	c.mu.Unlock()
+
+	delay := at.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,209 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/tpriime/ec2diff/pkg"
+	"github.com/tpriime/ec2diff/pkg/fakeec2"
+)
+
+// newFakeEC2 starts a fake EC2 endpoint serving count instances, named i-000 onwards.
+func newFakeEC2(t *testing.T, count int) *fakeec2.Server {
+	server := fakeec2.NewServer()
+	t.Cleanup(server.Close)
+	server.SetEnv(t)
+	for i := range count {
+		server.AddInstances(newInstance(fmt.Sprintf("i-%03d", i)))
+	}
+	return server
+}
+
+func newInstance(id string) types.Instance {
+	return types.Instance{InstanceId: &id, InstanceType: types.InstanceTypeT3Micro}
+}
+
+func TestFetch_RetriesThrottledCalls(t *testing.T) {
+	server := newFakeEC2(t, 1)
+	server.Throttle(2)
+
+	fetcher, err := NewAwsFetcher(t.Context(), 5, WithRetries("standard", 3, time.Millisecond))
+	assert.NoError(t, err)
+
+	result := pkg.InstanceMap{}
+	err = fetcher.Fetch(t.Context(), func(page int, instances pkg.InstanceMap) bool {
+		maps.Copy(result, instances)
+		return true
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, result, "i-000")
+	assert.Len(t, server.Requests(), 3)
+}
+
+func TestConfigureCalls(t *testing.T) {
+	t.Run("should keep the loaded config by default", func(t *testing.T) {
+		cfg := awssdk.Config{RetryMode: awssdk.RetryModeAdaptive, RetryMaxAttempts: 5}
+
+		assert.NoError(t, configureCalls(&cfg, &options{}))
+		assert.IsType(t, &retry.AdaptiveMode{}, cfg.Retryer())
+		assert.Equal(t, 5, cfg.RetryMaxAttempts)
+		assert.Nil(t, cfg.HTTPClient)
+	})
+
+	t.Run("should override the loaded config", func(t *testing.T) {
+		cfg := awssdk.Config{RetryMode: awssdk.RetryModeAdaptive, RetryMaxAttempts: 5}
+
+		err := configureCalls(&cfg, &options{retryMode: "standard", maxAttempts: 7, maxBackoff: time.Second, callTimeout: time.Second})
+		assert.NoError(t, err)
+		assert.IsType(t, &retry.Standard{}, cfg.Retryer())
+		assert.Equal(t, 7, cfg.RetryMaxAttempts)
+		assert.Equal(t, 7, cfg.Retryer().MaxAttempts())
+		assert.NotNil(t, cfg.HTTPClient)
+	})
+
+	t.Run("should reject unknown modes", func(t *testing.T) {
+		assert.Error(t, configureCalls(&awssdk.Config{}, &options{retryMode: "eager"}))
+	})
+}
+
+func TestFetch_ReturnsFailedPage(t *testing.T) {
+	server := newFakeEC2(t, 12)
+
+	fetcher, err := NewAwsFetcher(t.Context(), 5, WithRetries("", 2, time.Millisecond))
+	assert.NoError(t, err)
+
+	pages := 0
+	err = fetcher.Fetch(t.Context(), func(page int, instances pkg.InstanceMap) bool {
+		pages++
+		server.Throttle(2) // fail every attempt at the next page
+		return true
+	})
+
+	var pageErr *pkg.PageError
+	if assert.ErrorAs(t, err, &pageErr) {
+		assert.Equal(t, 2, pageErr.Page)
+		assert.Equal(t, "us-east-1", pageErr.Region)
+		assert.ErrorContains(t, pageErr, "RequestLimitExceeded")
+	}
+	assert.Equal(t, 1, pages, "pages after the failed one cannot be requested")
+}
+
+func TestFetch_InvalidFilterFailsWholeFetch(t *testing.T) {
+	server := newFakeEC2(t, idBatchSize+10)
+
+	fetcher, err := NewAwsFetcher(t.Context(), 5, WithRetries("", 3, time.Millisecond),
+		WithFilters(Filter{Name: "bogus-name", Values: []string{"x"}}))
+	assert.NoError(t, err)
+
+	err = fetcher.Fetch(t.Context(), func(int, pkg.InstanceMap) bool { return true })
+
+	assert.ErrorContains(t, err, "InvalidParameterValue")
+	_, partial := pkg.FailedPages(err)
+	assert.False(t, partial, "client errors should not be reported as failed pages")
+	assert.Len(t, server.Requests(), 1, "client errors should not be retried")
+
+	ids := make([]string, 0, idBatchSize+10)
+	for i := range idBatchSize + 10 {
+		ids = append(ids, fmt.Sprintf("i-%03d", i))
+	}
+	err = fetcher.(pkg.IDLiveFetcher).FetchByIDs(t.Context(), ids, func(int, pkg.InstanceMap) bool { return true })
+
+	assert.ErrorContains(t, err, "InvalidParameterValue")
+	assert.Len(t, server.Requests(), 2, "later batches should not be requested")
+}
+
+func TestFetch_InstanceIDsInBatches(t *testing.T) {
+	server := newFakeEC2(t, idBatchSize+10)
+
+	ids := make([]string, 0, idBatchSize+10)
+	for i := range idBatchSize + 10 {
+		ids = append(ids, fmt.Sprintf("i-%03d", i))
+	}
+	fetcher, err := NewAwsFetcher(t.Context(), 1000, WithInstanceIDs(ids...))
+	assert.NoError(t, err)
+
+	result := pkg.InstanceMap{}
+	err = fetcher.Fetch(t.Context(), func(page int, instances pkg.InstanceMap) bool {
+		maps.Copy(result, instances)
+		return true
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, result, idBatchSize+10)
+	assert.Len(t, server.Requests(), 2, "IDs should be sent in batches within the filter value limit")
+}
+
+func TestFetchByIDs_ContinuesAfterFailedBatch(t *testing.T) {
+	server := newFakeEC2(t, idBatchSize+10)
+	server.Throttle(2)
+
+	fetcher, err := NewAwsFetcher(t.Context(), 1000, WithRetries("", 2, time.Millisecond))
+	assert.NoError(t, err)
+
+	ids := make([]string, 0, idBatchSize+10)
+	for i := range idBatchSize + 10 {
+		ids = append(ids, fmt.Sprintf("i-%03d", i))
+	}
+	result := map[int]int{}
+	err = fetcher.(pkg.IDLiveFetcher).FetchByIDs(t.Context(), ids, func(page int, instances pkg.InstanceMap) bool {
+		result[page] = len(instances)
+		return true
+	})
+
+	failed, partial := pkg.FailedPages(err)
+	assert.True(t, partial)
+	assert.Len(t, failed, 1)
+	assert.Equal(t, 1, failed[0].Page)
+	assert.Equal(t, map[int]int{2: 10}, result, "the second batch should still be fetched")
+}
+
+func TestFetch_CallTimeout(t *testing.T) {
+	server := newFakeEC2(t, 1)
+	server.Delay(time.Second)
+
+	fetcher, err := NewAwsFetcher(t.Context(), 5, WithRetries("", 2, time.Millisecond), WithCallTimeout(20*time.Millisecond))
+	assert.NoError(t, err)
+
+	start := time.Now()
+	err = fetcher.Fetch(t.Context(), func(int, pkg.InstanceMap) bool { return true })
+
+	var pageErr *pkg.PageError
+	assert.ErrorAs(t, err, &pageErr)
+	assert.Len(t, server.Requests(), 2, "timed out attempts should be retried")
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestFetch_RateLimit(t *testing.T) {
+	newFakeEC2(t, 15)
+
+	fetcher, err := NewAwsFetcher(t.Context(), 5, WithRateLimit(20))
+	assert.NoError(t, err)
+
+	start := time.Now()
+	err = fetcher.Fetch(t.Context(), func(int, pkg.InstanceMap) bool { return true })
+
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond, "3 requests at 20 per second should take 2 intervals")
+}
+
+func TestFailedPages(t *testing.T) {
+	pageErr := &pkg.PageError{Page: 3, Region: "eu-west-1", Err: errors.New("throttled")}
+
+	failed, partial := pkg.FailedPages(errors.Join(pageErr, fmt.Errorf("wrapped: %w", pageErr)))
+	assert.True(t, partial)
+	assert.Equal(t, []pkg.FailedPage{
+		{Page: 3, Region: "eu-west-1", Error: "throttled"},
+		{Page: 3, Region: "eu-west-1", Error: "throttled"},
+	}, failed)
+
+	_, partial = pkg.FailedPages(errors.Join(pageErr, errors.New("access denied")))
+	assert.False(t, partial, "any other error fails the fetch as a whole")
+}
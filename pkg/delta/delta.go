@@ -0,0 +1,207 @@
+// Package delta compares the reports of two runs, classifying drift as new,
+// resolved, changed or unchanged.
+package delta
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tpriime/ec2diff/pkg"
+)
+
+// Run holds the reports of one run.
+type Run struct {
+	Reports []pkg.Report
+	Partial bool // Whether some instances were not checked, e.g. as pages failed to fetch
+}
+
+// savedReports matches both the JSON output and the snapshots written by -save.
+type savedReports struct {
+	Reports     *[]pkg.Report    `json:"reports"`
+	Partial     string           `json:"partial"`
+	FailedPages []pkg.FailedPage `json:"failed_pages"`
+}
+
+// Load reads the run saved at path, as JSON output, NDJSON output or a snapshot.
+// The run is partial if it records a reason to be, or failed pages.
+func Load(path string) (Run, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Run{}, err
+	}
+
+	var saved savedReports
+	if err := json.Unmarshal(data, &saved); err == nil && saved.Reports != nil {
+		return Run{Reports: *saved.Reports, Partial: saved.Partial != "" || len(saved.FailedPages) > 0}, nil
+	}
+
+	// Otherwise expect one report per line, then the summary line
+	var run Run
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var summary struct {
+			Summary     *pkg.Summary     `json:"summary"`
+			Partial     string           `json:"partial"`
+			FailedPages []pkg.FailedPage `json:"failed_pages"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &summary); err == nil && summary.Summary != nil {
+			run.Partial = summary.Partial != "" || len(summary.FailedPages) > 0
+			continue
+		}
+		var r pkg.Report
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil || r.InstanceID == "" {
+			return Run{}, fmt.Errorf("%s is not a saved report: invalid line %d", path, line)
+		}
+		run.Reports = append(run.Reports, r)
+	}
+	return run, scanner.Err()
+}
+
+// Compare classifies the reports of newer against those of older, by instance ID.
+//
+// Instances drifting only in newer are new, and those drifting only in older
+// are resolved; the latter are returned as reported in older. If newer is partial,
+// instances it does not report are left out rather than resolved, as they may not
+// have been checked. Instances drifting in both are changed or unchanged, with each
+// of their drifts classified the same way. Suppressed drift counts as no drift.
+// Unchanged reports are only kept with all set.
+func Compare(older, newer Run, all bool) []pkg.Report {
+	before, after := byInstance(older.Reports), byInstance(newer.Reports)
+	reported := map[string]struct{}{}
+	for _, r := range newer.Reports {
+		reported[r.InstanceID] = struct{}{}
+	}
+
+	var out []pkg.Report
+	for _, r := range newer.Reports {
+		if !drifting(r) {
+			continue
+		}
+
+		old, existed := before[r.InstanceID]
+		switch {
+		case !existed:
+			out = append(out, mark(r, pkg.ChangeNew))
+		case old.Comment != r.Comment:
+			out = append(out, mark(r, pkg.ChangeChanged))
+		default:
+			r = compareDrifts(old, r)
+			if r.Change == pkg.ChangeChanged || all {
+				out = append(out, r)
+			}
+		}
+	}
+
+	for _, r := range older.Reports {
+		if _, ok := after[r.InstanceID]; !drifting(r) || ok {
+			continue
+		}
+		if _, ok := reported[r.InstanceID]; !ok && newer.Partial {
+			continue
+		}
+		out = append(out, mark(r, pkg.ChangeResolved))
+	}
+	return out
+}
+
+// Summarize counts the reports returned by Compare by their change. Their comments
+// are left out, as resolved reports are as found in the older run.
+func Summarize(reports []pkg.Report) pkg.Summary {
+	changes := &pkg.ChangeSummary{}
+	for _, r := range reports {
+		switch r.Change {
+		case pkg.ChangeNew:
+			changes.New++
+		case pkg.ChangeResolved:
+			changes.Resolved++
+		case pkg.ChangeChanged:
+			changes.Changed++
+		case pkg.ChangeUnchanged:
+			changes.Unchanged++
+		}
+	}
+	return pkg.Summary{Total: len(reports), Changes: changes}
+}
+
+// byInstance maps the drifting reports by instance ID.
+func byInstance(reports []pkg.Report) map[string]pkg.Report {
+	out := map[string]pkg.Report{}
+	for _, r := range reports {
+		if drifting(r) {
+			out[r.InstanceID] = r
+		}
+	}
+	return out
+}
+
+// drifting reports whether r has drift that is not suppressed.
+func drifting(r pkg.Report) bool {
+	return r.Comment != pkg.CommentNoDriftDetected && !r.Suppressed
+}
+
+// mark sets change on the report and all of its unsuppressed drifts.
+func mark(r pkg.Report, change string) pkg.Report {
+	r.Change = change
+	r.Drifts = activeDrifts(r.Drifts)
+	for i := range r.Drifts {
+		r.Drifts[i].Change = change
+	}
+	return r
+}
+
+// compareDrifts classifies the drifts of a report present in both runs by attribute name.
+// The report is changed if any of its drifts is.
+func compareDrifts(older, newer pkg.Report) pkg.Report {
+	before := map[string]pkg.AttributeDrift{}
+	for _, d := range activeDrifts(older.Drifts) {
+		before[d.Name] = d
+	}
+
+	newer.Change = pkg.ChangeUnchanged
+	drifts := activeDrifts(newer.Drifts)
+	for i, d := range drifts {
+		old, ok := before[d.Name]
+		delete(before, d.Name)
+		switch {
+		case !ok:
+			drifts[i].Change = pkg.ChangeNew
+		case !cmp.Equal(old.Expected, d.Expected) || !cmp.Equal(old.Found, d.Found):
+			drifts[i].Change = pkg.ChangeChanged
+		default:
+			drifts[i].Change = pkg.ChangeUnchanged
+			continue
+		}
+		newer.Change = pkg.ChangeChanged
+	}
+
+	// Drifts no longer found, kept in the order they were reported
+	for _, d := range activeDrifts(older.Drifts) {
+		if _, ok := before[d.Name]; ok {
+			d.Change = pkg.ChangeResolved
+			drifts = append(drifts, d)
+			newer.Change = pkg.ChangeChanged
+		}
+	}
+
+	newer.Drifts = drifts
+	return newer
+}
+
+// activeDrifts returns a copy of the drifts that are not suppressed.
+func activeDrifts(drifts []pkg.AttributeDrift) []pkg.AttributeDrift {
+	active := []pkg.AttributeDrift{}
+	for _, d := range drifts {
+		if !d.Suppressed {
+			active = append(active, d)
+		}
+	}
+	return active
+}
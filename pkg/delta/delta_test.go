@@ -0,0 +1,145 @@
+package delta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tpriime/ec2diff/pkg"
+)
+
+func report(id, comment string, drifts ...pkg.AttributeDrift) pkg.Report {
+	if drifts == nil {
+		drifts = []pkg.AttributeDrift{}
+	}
+	return pkg.Report{InstanceID: id, Comment: comment, Drifts: drifts}
+}
+
+func TestCompare(t *testing.T) {
+	typeDrift := pkg.AttributeDrift{Name: pkg.AttrInstanceType, Expected: "t3.large", Found: "t3.micro"}
+	ownerDrift := pkg.AttributeDrift{Name: "tags.Owner", Expected: "bob", Found: "alice"}
+	ownerChanged := pkg.AttributeDrift{Name: "tags.Owner", Expected: "carol", Found: "alice"}
+
+	older := []pkg.Report{
+		report("i-same", pkg.CommentDriftDetected, typeDrift),
+		report("i-changed", pkg.CommentDriftDetected, typeDrift, ownerDrift),
+		report("i-fixed", pkg.CommentDriftDetected, typeDrift),
+		report("i-gone", pkg.CommentMissingState, typeDrift),
+		report("i-clean", pkg.CommentNoDriftDetected),
+	}
+	newer := []pkg.Report{
+		report("i-same", pkg.CommentDriftDetected, typeDrift),
+		report("i-changed", pkg.CommentDriftDetected, ownerChanged),
+		report("i-fixed", pkg.CommentNoDriftDetected),
+		report("i-clean", pkg.CommentDriftDetected, ownerDrift),
+	}
+
+	delta := Compare(Run{Reports: older}, Run{Reports: newer}, false)
+
+	changes := map[string]string{}
+	for _, r := range delta {
+		changes[r.InstanceID] = r.Change
+	}
+	assert.Equal(t, map[string]string{
+		"i-changed": pkg.ChangeChanged,
+		"i-clean":   pkg.ChangeNew,
+		"i-fixed":   pkg.ChangeResolved,
+		"i-gone":    pkg.ChangeResolved,
+	}, changes, "unchanged drift should be left out")
+
+	assert.Equal(t, []pkg.AttributeDrift{
+		{Name: "tags.Owner", Expected: "carol", Found: "alice", Change: pkg.ChangeChanged},
+		{Name: pkg.AttrInstanceType, Expected: "t3.large", Found: "t3.micro", Change: pkg.ChangeResolved},
+	}, delta[0].Drifts)
+
+	all := Compare(Run{Reports: older}, Run{Reports: newer}, true)
+	assert.Len(t, all, 5)
+	assert.Equal(t, pkg.ChangeUnchanged, all[0].Change)
+	assert.Equal(t, pkg.ChangeUnchanged, all[0].Drifts[0].Change)
+
+	assert.Equal(t, pkg.Summary{
+		Total:   5,
+		Changes: &pkg.ChangeSummary{New: 1, Resolved: 2, Changed: 1, Unchanged: 1},
+	}, Summarize(all))
+}
+
+func TestCompare_CommentChanged(t *testing.T) {
+	drift := pkg.AttributeDrift{Name: pkg.AttrInstanceState, Expected: "-", Found: "running"}
+	older := []pkg.Report{report("i-1", pkg.CommentDriftDetected, drift)}
+	newer := []pkg.Report{report("i-1", pkg.CommentMissingLive, drift)}
+
+	delta := Compare(Run{Reports: older}, Run{Reports: newer}, false)
+
+	assert.Len(t, delta, 1)
+	assert.Equal(t, pkg.ChangeChanged, delta[0].Change)
+}
+
+func TestCompare_SuppressedIsNoDrift(t *testing.T) {
+	suppressed := report("i-1", pkg.CommentDriftDetected, pkg.AttributeDrift{Name: pkg.AttrInstanceState, Suppressed: true})
+	suppressed.Suppressed = true
+
+	assert.Empty(t, Compare(Run{Reports: []pkg.Report{suppressed}}, Run{}, true))
+	assert.Empty(t, Compare(Run{}, Run{Reports: []pkg.Report{suppressed}}, true))
+}
+
+func TestCompare_PartialRun(t *testing.T) {
+	drift := pkg.AttributeDrift{Name: pkg.AttrInstanceState, Expected: "stopped", Found: "running"}
+	older := Run{Reports: []pkg.Report{
+		report("i-fixed", pkg.CommentDriftDetected, drift),
+		report("i-unchecked", pkg.CommentDriftDetected, drift),
+	}}
+	newer := Run{Reports: []pkg.Report{report("i-fixed", pkg.CommentNoDriftDetected)}, Partial: true}
+
+	delta := Compare(older, newer, false)
+
+	assert.Len(t, delta, 1, "instances not reported by a partial run should not be resolved")
+	assert.Equal(t, "i-fixed", delta[0].InstanceID)
+	assert.Equal(t, pkg.ChangeResolved, delta[0].Change)
+}
+
+func TestLoad_Partial(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"output.json":    `{"reports": [], "summary": {}, "failed_pages": [{"page": 2, "error": "throttled"}]}`,
+		"snapshot.json":  `{"id": "20260601T000000Z", "partial": "stopped after 1 drift", "reports": []}`,
+		"output.ndjson":  "{\"instance_id\": \"i-1\"}\n{\"summary\": {\"total\": 1}, \"failed_pages\": [{\"page\": 2}]}\n",
+		"stopped.json":   `{"reports": [], "summary": {}, "partial": "stopped after 1 drift"}`,
+		"stopped.ndjson": "{\"instance_id\": \"i-1\"}\n{\"summary\": {\"total\": 1}, \"partial\": \"stopped after 1 drift\"}\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			os.WriteFile(path, []byte(content), 0o644)
+
+			run, err := Load(path)
+
+			assert.NoError(t, err)
+			assert.True(t, run.Partial)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"output.json":   `{"summary": {"total": 1}, "reports": [{"instance_id": "i-1", "comment": "Drifts detected"}]}`,
+		"snapshot.json": `{"id": "20260601T000000Z", "reports": [{"instance_id": "i-1", "comment": "Drifts detected"}]}`,
+		"output.ndjson": "{\"instance_id\": \"i-1\", \"comment\": \"Drifts detected\"}\n\n{\"summary\": {\"total\": 1}}\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			os.WriteFile(path, []byte(content), 0o644)
+
+			run, err := Load(path)
+
+			assert.NoError(t, err)
+			assert.Equal(t, []pkg.Report{{InstanceID: "i-1", Comment: pkg.CommentDriftDetected}}, run.Reports)
+			assert.False(t, run.Partial)
+		})
+	}
+
+	path := filepath.Join(dir, "other.json")
+	os.WriteFile(path, []byte(`{"resources": []}`), 0o644)
+	_, err := Load(path)
+	assert.ErrorContains(t, err, "is not a saved report")
+}
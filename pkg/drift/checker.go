@@ -6,71 +6,211 @@ import (
 
 	"github.com/tpriime/ec2diff/pkg"
 	"github.com/tpriime/ec2diff/pkg/logger"
+	"github.com/tpriime/ec2diff/pkg/tagglob"
 )
 
+// Number of pages whose instances are queued for the workers while an earlier page
+// finishes, or waits to be sent on.
+const pagesAhead = 2
+
 // driftChecker implements the DriftChecker interface.
 type driftChecker struct {
 	// number of concurrent workers
 	workers int
+
+	// globs of tag keys left out of the comparison
+	ignoredTags []string
+}
+
+// Option configures a driftChecker.
+type Option func(*driftChecker)
+
+// WithIgnoredTags leaves tag keys matching any of the globs (e.g. aws:*) out of the comparison.
+func WithIgnoredTags(globs ...string) Option {
+	return func(d *driftChecker) {
+		d.ignoredTags = append(d.ignoredTags, globs...)
+	}
 }
 
 // NewDriftChecker returns a new instance of driftChecker.
-func NewDriftChecker(workers int) pkg.DriftChecker {
-	return &driftChecker{workers: workers}
+func NewDriftChecker(workers int, opts ...Option) pkg.DriftChecker {
+	d := &driftChecker{workers: workers}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
 }
 
 // CheckDrift compares liveInstances with stateInstances to detect drift.
 //
 // It returns a list of reports indicating changed or missing attributes.
 func (d driftChecker) CheckDrift(ctx context.Context, liveInstances, stateInstances pkg.InstanceMap, attributes []string) []pkg.Report {
-	ctx = logger.With(ctx, "op", "drift.CheckDrift")
+	pages := make(chan pkg.Page, 1)
+	pages <- pkg.Page{Number: 1, Instances: liveInstances}
+	close(pages)
 
-	jobs := make(chan string, len(liveInstances))
-	results := make(chan pkg.Report, len(liveInstances))
+	var reports []pkg.Report
+	for checked := range d.CheckPages(ctx, pages, stateInstances, attributes) {
+		reports = append(reports, checked.Reports...)
+	}
+	return reports
+}
 
-	var wg sync.WaitGroup
+// pendingPage is a page whose instances are being checked.
+type pendingPage struct {
+	pkg.CheckedPage
 
-	// Start a fixed pool of workers
-	for i := 0; i < d.workers; i++ {
+	mu        sync.Mutex
+	remaining int           // Instances left to check
+	done      chan struct{} // Closed once every instance is checked
+}
+
+// add records the report of one of the page's instances.
+func (p *pendingPage) add(report pkg.Report) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Reports = append(p.Reports, report)
+	if p.remaining--; p.remaining == 0 {
+		close(p.done)
+	}
+}
+
+// checkJob is an instance of a page to check.
+type checkJob struct {
+	page     *pendingPage
+	id       string
+	instance pkg.Instance
+}
+
+// CheckPages compares the live instances of every page with stateInstances on a fixed
+// pool of workers, started once and fed instances from every page as they arrive.
+//
+// A page is sent on once all of its instances are checked, and only after the pages
+// received before it. The instances of up to pagesAhead later pages are queued in the
+// meantime, so workers check the next pages while an earlier one finishes.
+func (d driftChecker) CheckPages(ctx context.Context, pages <-chan pkg.Page, stateInstances pkg.InstanceMap, attributes []string) <-chan pkg.CheckedPage {
+	ctx = logger.With(ctx, "op", "drift.CheckPages")
+
+	jobs := make(chan checkJob, d.workers)
+	order := make(chan *pendingPage, pagesAhead)
+	out := make(chan pkg.CheckedPage)
+
+	// Start a fixed pool of workers, shared by every page
+	var wg sync.WaitGroup
+	for i := 0; i < max(d.workers, 1); i++ {
 		wg.Add(1)
 		go func(workerID int) {
 			defer wg.Done()
-			for instanceID := range jobs {
-				var report pkg.Report
-				logger.Info(ctx, "Comparing live and state for instance", "worker", workerID, "instanceID", instanceID)
-
-				if stateInst, found := stateInstances[instanceID]; found {
-					report = compareState(instanceID, instanceToState(liveInstances[instanceID]), instanceToState(stateInst), attributes)
-				} else {
-					logger.Info(ctx, "Instance missing in state", "worker", workerID, "instanceID", instanceID)
-					report = reportMissing(instanceID, instanceToState(liveInstances[instanceID]), attributes)
-				}
-
-				results <- report
+			for job := range jobs {
+				job.page.add(d.check(ctx, workerID, job.id, job.instance, stateInstances, attributes))
 			}
 		}(i)
 	}
 
-	// Feed jobs to the queue
+	// Feed the instances of each page to the workers, queueing pages in the order received
 	go func() {
-		for instanceID := range liveInstances {
-			jobs <- instanceID
+		defer close(order)
+		for page := range pages {
+			pending := &pendingPage{
+				CheckedPage: pkg.CheckedPage{Page: page, Reports: make([]pkg.Report, 0, len(page.Instances))},
+				remaining:   len(page.Instances),
+				done:        make(chan struct{}),
+			}
+			if pending.remaining == 0 {
+				close(pending.done)
+			}
+			order <- pending
+			for id, inst := range page.Instances {
+				jobs <- checkJob{page: pending, id: id, instance: inst}
+			}
 		}
 		close(jobs)
 	}()
 
-	// Wait for workers to finish and close results
+	// Send pages on in order as they complete
 	go func() {
+		defer close(out)
+		for pending := range order {
+			<-pending.done
+			logger.Info(ctx, "Drift reports collected", "page", pending.Number, "reports", len(pending.Reports))
+			out <- pending.CheckedPage
+		}
 		wg.Wait()
-		close(results)
 	}()
 
-	// Collect the results
+	return out
+}
+
+// check compares the live instance with ID instanceID with the state instance of the same ID, if any.
+func (d driftChecker) check(ctx context.Context, workerID int, instanceID string, live pkg.Instance, stateInstances pkg.InstanceMap, attributes []string) pkg.Report {
+	var report pkg.Report
+	logger.Info(ctx, "Comparing live and state for instance", "worker", workerID, "instanceID", instanceID)
+
+	liveInst := d.withoutIgnoredTags(live)
+	if stateInst, found := stateInstances[instanceID]; found {
+		liveInst, stateInst, attrs := alignSecurityGroups(liveInst, d.withoutIgnoredTags(stateInst), attributes)
+		attrs = knownAttributes(attrs, stateInst.Unknown)
+		report = compareState(instanceID, instanceToState(liveInst), instanceToState(stateInst), attrs)
+		report.Address = stateInst.Address
+		report.Sources, report.Conflict = sources(stateInst)
+	} else {
+		logger.Info(ctx, "Instance missing in state", "worker", workerID, "instanceID", instanceID)
+		report = reportMissing(instanceID, instanceToState(liveInst), attributes)
+	}
+
+	// Tag the report with where the live instance was found
+	report.Account = live.Account
+	report.Region = live.Region
+	return report
+}
+
+// CheckMissingLive reports every state instance whose ID is absent from seenIDs.
+//
+// Since live instances arrive page by page, this must only be called after the
+// fetch has completed, otherwise instances on later pages are falsely reported.
+func (d driftChecker) CheckMissingLive(ctx context.Context, seenIDs map[string]struct{}, stateInstances pkg.InstanceMap, attributes []string) []pkg.Report {
+	ctx = logger.With(ctx, "op", "drift.CheckMissingLive")
+
 	var reports []pkg.Report
-	for r := range results {
-		reports = append(reports, r)
+	for instanceID, stateInst := range stateInstances {
+		if _, seen := seenIDs[instanceID]; seen {
+			continue
+		}
+		logger.Info(ctx, "Instance missing live", "instanceID", instanceID)
+		attrs := knownAttributes(attributes, stateInst.Unknown)
+		report := reportMissingLive(instanceID, instanceToState(d.withoutIgnoredTags(stateInst)), attrs)
+		report.Address = stateInst.Address
+		report.Sources, report.Conflict = sources(stateInst)
+		reports = append(reports, report)
 	}
 
-	logger.Info(ctx, "Drift reports collected", "reports", len(reports))
+	logger.Info(ctx, "Missing live reports collected", "reports", len(reports))
 	return reports
 }
+
+// sources returns the state file inst was read from, if known, or every state
+// claiming it along with true if several do.
+func sources(inst pkg.Instance) ([]string, bool) {
+	if len(inst.ClaimedBy) > 1 {
+		return inst.ClaimedBy, true
+	}
+	if inst.Source == "" {
+		return nil, false
+	}
+	return []string{inst.Source}, false
+}
+
+// withoutIgnoredTags returns inst without the tags whose keys match an ignored glob.
+func (d driftChecker) withoutIgnoredTags(inst pkg.Instance) pkg.Instance {
+	if len(d.ignoredTags) == 0 || inst.Tags == nil {
+		return inst
+	}
+	tags := make(map[string]string, len(inst.Tags))
+	for key, value := range inst.Tags {
+		if !tagglob.MatchAny(key, d.ignoredTags) {
+			tags[key] = value
+		}
+	}
+	inst.Tags = tags
+	return inst
+}
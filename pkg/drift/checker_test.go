@@ -1,10 +1,17 @@
 package drift
 
 import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/tpriime/ec2diff/pkg"
+	"github.com/tpriime/ec2diff/pkg/logger"
 )
 
 // mockState creates a simple instance used in drift tests.
@@ -72,3 +79,171 @@ func TestCheckDrift_CustomAttributes(t *testing.T) {
 
 	assert.Empty(t, reports[0].Drifts)
 }
+
+func TestCheckMissingLive(t *testing.T) {
+	state := pkg.InstanceMap{
+		"i-1": mockState("i-1", "t2.micro", "running", "key"),
+		"i-2": mockState("i-2", "t3.small", "running", "key"),
+	}
+	seen := map[string]struct{}{"i-1": {}}
+
+	reports := NewDriftChecker(2).CheckMissingLive(t.Context(), seen, state,
+		[]string{pkg.AttrInstanceType, pkg.AttrInstanceState})
+
+	assert.Len(t, reports, 1)
+	assert.Equal(t, "i-2", reports[0].InstanceID)
+	assert.Equal(t, pkg.CommentMissingLive, reports[0].Comment)
+	assert.Len(t, reports[0].Drifts, 2)
+}
+
+func TestCheckMissingLive_AllSeen(t *testing.T) {
+	state := pkg.InstanceMap{"i-1": mockState("i-1", "t2.micro", "running", "key")}
+	seen := map[string]struct{}{"i-1": {}}
+
+	reports := NewDriftChecker(2).CheckMissingLive(t.Context(), seen, state, []string{pkg.AttrInstanceType})
+
+	assert.Empty(t, reports)
+}
+
+func TestCheckDrift_SkipsUnknownAttributes(t *testing.T) {
+	live := pkg.InstanceMap{"i-1": mockState("i-1", "t2.micro", "running", "key1")}
+	stateInst := mockState("i-1", "t2.micro", "running", "")
+	stateInst.Unknown = []string{pkg.AttrKeyName}
+	state := pkg.InstanceMap{"i-1": stateInst}
+
+	reports := NewDriftChecker(2).CheckDrift(t.Context(), live, state, []string{pkg.AttrKeyName, pkg.AttrInstanceType})
+
+	assert.Len(t, reports, 1)
+	assert.Empty(t, reports[0].Drifts)
+}
+
+func TestCheckDrift_TagsAccountAndRegion(t *testing.T) {
+	liveInst := mockState("i-1", "t2.micro", "running", "key")
+	liveInst.Account = "123456789012"
+	liveInst.Region = "eu-west-1"
+	live := pkg.InstanceMap{"i-1": liveInst}
+	state := pkg.InstanceMap{"i-1": mockState("i-1", "t2.micro", "running", "key")}
+
+	reports := NewDriftChecker(2).CheckDrift(t.Context(), live, state, []string{pkg.AttrInstanceType})
+
+	assert.Len(t, reports, 1)
+	assert.Equal(t, "123456789012", reports[0].Account)
+	assert.Equal(t, "eu-west-1", reports[0].Region)
+}
+
+func TestCheckDrift_CarriesAddress(t *testing.T) {
+	live := pkg.InstanceMap{"i-1": mockState("i-1", "t2.micro", "running", "key")}
+	stateInst := mockState("i-1", "t2.micro", "running", "key")
+	stateInst.Address = `module.web.aws_instance.app["blue"]`
+	gone := mockState("i-2", "t2.micro", "running", "key")
+	gone.Address = "aws_instance.db[0]"
+	state := pkg.InstanceMap{"i-1": stateInst, "i-2": gone}
+
+	reports := NewDriftChecker(2).CheckDrift(t.Context(), live, state, []string{pkg.AttrInstanceType})
+	missing := NewDriftChecker(2).CheckMissingLive(t.Context(), map[string]struct{}{"i-1": {}}, state, []string{pkg.AttrInstanceType})
+
+	assert.Equal(t, `module.web.aws_instance.app["blue"]`, reports[0].Address)
+	assert.Equal(t, "aws_instance.db[0]", missing[0].Address)
+}
+
+func TestCheckDrift_IgnoredTags(t *testing.T) {
+	liveInst := mockState("i-1", "t2.micro", "running", "key")
+	liveInst.Tags = map[string]string{
+		"Name":                       "web",
+		"aws:backup:source-resource": "x",
+		"karpenter.sh/nodepool":      "default",
+		"kubernetes.io/cluster/x":    "owned",
+	}
+	stateInst := mockState("i-1", "t2.micro", "running", "key")
+	stateInst.Tags = map[string]string{"Name": "web"}
+	live := pkg.InstanceMap{"i-1": liveInst}
+	state := pkg.InstanceMap{"i-1": stateInst}
+
+	reports := NewDriftChecker(2, WithIgnoredTags("aws:*", "karpenter.sh/*", "kubernetes.io/*")).CheckDrift(t.Context(), live, state, []string{pkg.AttrTags})
+
+	assert.Len(t, reports, 1)
+	assert.Empty(t, reports[0].Drifts)
+	assert.Len(t, live["i-1"].Tags, 4, "the fetched instance should not be modified")
+}
+
+func TestCheckPages_InOrder(t *testing.T) {
+	state := pkg.InstanceMap{}
+	pages := make(chan pkg.Page, 20)
+	for n := 1; n <= 20; n++ {
+		live := pkg.InstanceMap{}
+		for i := range n % 4 {
+			id := fmt.Sprintf("i-%d-%d", n, i)
+			live[id] = mockState(id, "t2.micro", "running", "key")
+			state[id] = mockState(id, "t2.micro", "stopped", "key")
+		}
+		pages <- pkg.Page{Number: n, Instances: live}
+	}
+	close(pages)
+
+	var numbers []int
+	for checked := range NewDriftChecker(3).CheckPages(t.Context(), pages, state, []string{pkg.AttrInstanceState}) {
+		numbers = append(numbers, checked.Number)
+		assert.Len(t, checked.Reports, len(checked.Instances), "page %d", checked.Number)
+		for _, r := range checked.Reports {
+			assert.Contains(t, checked.Instances, r.InstanceID)
+			assert.Len(t, r.Drifts, 1)
+		}
+	}
+
+	assert.Len(t, numbers, 20)
+	assert.IsIncreasing(t, numbers, "pages should be sent on in the order received")
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use, to capture logs.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestCheckPages_ChecksAhead(t *testing.T) {
+	var logs syncBuffer
+	logger.Init(&logs, logger.LevelInfo, logger.FormatText)
+	t.Cleanup(func() { logger.Init(os.Stderr, logger.LevelInfo, logger.FormatText) })
+
+	pages := make(chan pkg.Page, 3)
+	for n := 1; n <= 3; n++ {
+		id := fmt.Sprintf("i-%d", n)
+		pages <- pkg.Page{Number: n, Instances: pkg.InstanceMap{id: mockState(id, "t2.micro", "running", "key")}}
+	}
+	close(pages)
+
+	out := NewDriftChecker(1).CheckPages(t.Context(), pages, pkg.InstanceMap{}, []string{pkg.AttrInstanceState})
+
+	// No page is received, so the first one cannot be sent on
+	assert.Eventually(t, func() bool {
+		return strings.Contains(logs.String(), "instanceID=i-3")
+	}, time.Second, time.Millisecond, "later pages should be checked while the first waits to be sent on")
+
+	var numbers []int
+	for checked := range out {
+		numbers = append(numbers, checked.Number)
+	}
+	assert.Equal(t, []int{1, 2, 3}, numbers)
+}
+
+func TestCheckPages_NoPages(t *testing.T) {
+	pages := make(chan pkg.Page)
+	close(pages)
+
+	out := NewDriftChecker(2).CheckPages(t.Context(), pages, pkg.InstanceMap{}, nil)
+
+	_, ok := <-out
+	assert.False(t, ok)
+}
@@ -1,9 +1,9 @@
 package drift
 
 import (
+	"maps"
 	"slices"
 
-	"github.com/google/go-cmp/cmp"
 	"github.com/tpriime/ec2diff/pkg"
 )
 
@@ -21,9 +21,14 @@ func compareState(id string, stateA, stateB state, attrs []string) pkg.Report {
 			continue
 		}
 		valueB, ok := stateB[attr]
-		if !ok || !cmp.Equal(valueA, valueB) {
-			drifts = append(drifts, pkg.AttributeDrift{Name: attr, Expected: valueA, Found: valueB})
+		if ok && equal(attr, valueA, valueB) {
+			continue
+		}
+		if mapA, mapB, isMap := asMaps(valueA, valueB); ok && isMap {
+			drifts = append(drifts, compareMap(attr, mapA, mapB)...)
+			continue
 		}
+		drifts = append(drifts, pkg.AttributeDrift{Name: attr, Expected: valueA, Found: valueB})
 	}
 
 	// Find extra attributes present in stateB but missing in stateA
@@ -49,6 +54,33 @@ func compareState(id string, stateA, stateB state, attrs []string) pkg.Report {
 	}
 }
 
+// compareMap breaks a drifted map attribute into one drift per key, named attr.key
+// (e.g. tags.Owner). Keys only present on one side have "-" on the other.
+func compareMap(attr string, mapA, mapB map[string]string) []pkg.AttributeDrift {
+	var drifts []pkg.AttributeDrift
+	for _, key := range slices.Sorted(maps.Keys(mapA)) {
+		valueB, ok := mapB[key]
+		if !ok {
+			drifts = append(drifts, pkg.AttributeDrift{Name: attr + "." + key, Expected: mapA[key], Found: "-"})
+		} else if valueB != mapA[key] {
+			drifts = append(drifts, pkg.AttributeDrift{Name: attr + "." + key, Expected: mapA[key], Found: valueB})
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(mapB)) {
+		if _, ok := mapA[key]; !ok {
+			drifts = append(drifts, pkg.AttributeDrift{Name: attr + "." + key, Expected: "-", Found: mapB[key]})
+		}
+	}
+	return drifts
+}
+
+// asMaps returns both values as string maps, if they are.
+func asMaps(a, b any) (map[string]string, map[string]string, bool) {
+	mapA, okA := a.(map[string]string)
+	mapB, okB := b.(map[string]string)
+	return mapA, mapB, okA && okB
+}
+
 // reportMissing generates a drift report for an instance missing from stateB.
 // It assumes the instance is present only in stateA and marks all attributes as missing.
 func reportMissing(id string, stateA state, attrs []string) pkg.Report {
@@ -67,13 +99,105 @@ func reportMissing(id string, stateA state, attrs []string) pkg.Report {
 	}
 }
 
+// reportMissingLive generates a drift report for an instance present only in stateB.
+// It is the inverse of reportMissing: every attribute is expected but not found live.
+func reportMissingLive(id string, stateB state, attrs []string) pkg.Report {
+	drifts := []pkg.AttributeDrift{}
+	for attr, value := range stateB {
+		// filter to include only selected attributes
+		if !slices.Contains(attrs, attr) {
+			continue
+		}
+		drifts = append(drifts, pkg.AttributeDrift{Name: attr, Expected: "-", Found: value})
+	}
+	return pkg.Report{
+		InstanceID: id,
+		Drifts:     drifts,
+		Comment:    pkg.CommentMissingLive,
+	}
+}
+
+// knownAttributes filters out attributes whose expected value is unknown.
+func knownAttributes(attrs, unknown []string) []string {
+	if len(unknown) == 0 {
+		return attrs
+	}
+	known := make([]string, 0, len(attrs))
+	for _, attr := range attrs {
+		if !slices.Contains(unknown, attr) {
+			known = append(known, attr)
+		}
+	}
+	return known
+}
+
+// alignSecurityGroups rewrites security_groups on both instances to the identifiers
+// the target declares them by, ignoring order.
+//
+// Groups are compared by ID, unless the target only names them, as EC2-Classic and
+// default-VPC configs do. Comparing names otherwise flags VPC instances whose state
+// leaves security_groups empty in favour of vpc_security_group_ids.
+//
+// As both attributes then hold the same groups, the attrs returned keep only the one
+// the target sets when both are selected, so that a changed group is reported once.
+func alignSecurityGroups(live, target pkg.Instance, attrs []string) (pkg.Instance, pkg.Instance, []string) {
+	live.VpcSecurityGroupIDs = sorted(live.VpcSecurityGroupIDs)
+	target.VpcSecurityGroupIDs = sorted(target.VpcSecurityGroupIDs)
+
+	if len(target.VpcSecurityGroupIDs) == 0 && len(target.SecurityGroups) > 0 {
+		live.SecurityGroups = sorted(live.SecurityGroups)
+		target.SecurityGroups = sorted(target.SecurityGroups)
+		return live, target, withoutDuplicate(attrs, pkg.AttrSecurityGroups, pkg.AttrVpcSecurityGroupIDs)
+	}
+
+	live.SecurityGroups = live.VpcSecurityGroupIDs
+	target.SecurityGroups = target.VpcSecurityGroupIDs
+	if !slices.Contains(target.Unknown, pkg.AttrVpcSecurityGroupIDs) {
+		// IDs are known even if the names were not
+		target.Unknown = slices.DeleteFunc(slices.Clone(target.Unknown), func(attr string) bool {
+			return attr == pkg.AttrSecurityGroups
+		})
+	}
+	return live, target, withoutDuplicate(attrs, pkg.AttrVpcSecurityGroupIDs, pkg.AttrSecurityGroups)
+}
+
+// withoutDuplicate returns attrs without duplicate, if attrs also holds kept.
+func withoutDuplicate(attrs []string, kept, duplicate string) []string {
+	if !slices.Contains(attrs, kept) || !slices.Contains(attrs, duplicate) {
+		return attrs
+	}
+	return slices.DeleteFunc(slices.Clone(attrs), func(attr string) bool {
+		return attr == duplicate
+	})
+}
+
+// sorted returns a sorted copy of s.
+func sorted(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	s = slices.Clone(s)
+	slices.Sort(s)
+	return s
+}
+
 func instanceToState(i pkg.Instance) state {
 	return state{
-		pkg.AttrInstanceType:   i.Type,
-		pkg.AttrInstanceState:  i.State,
-		pkg.AttrKeyName:        i.KeyName,
-		pkg.AttrTags:           i.Tags,
-		pkg.AttrSecurityGroups: i.SecurityGroups,
-		pkg.AttrPublicIP:       i.PublicIP,
+		pkg.AttrInstanceType:        i.Type,
+		pkg.AttrInstanceState:       i.State,
+		pkg.AttrKeyName:             i.KeyName,
+		pkg.AttrTags:                i.Tags,
+		pkg.AttrSecurityGroups:      i.SecurityGroups,
+		pkg.AttrPublicIP:            i.PublicIP,
+		pkg.AttrPrivateIP:           i.PrivateIP,
+		pkg.AttrAmi:                 i.Ami,
+		pkg.AttrAvailabilityZone:    i.AvailabilityZone,
+		pkg.AttrSubnetID:            i.SubnetID,
+		pkg.AttrVpcID:               i.VpcID,
+		pkg.AttrVpcSecurityGroupIDs: i.VpcSecurityGroupIDs,
+		pkg.AttrMonitoring:          i.Monitoring,
+		pkg.AttrArchitecture:        i.Architecture,
+		pkg.AttrVirtualizationType:  i.VirtualizationType,
+		pkg.AttrIamInstanceProfile:  i.IamInstanceProfile,
 	}
 }
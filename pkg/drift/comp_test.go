@@ -101,3 +101,105 @@ func TestCompareMissing(t *testing.T) {
 		assert.Equal(t, "-", d.Found)
 	}
 }
+
+func TestCompareMissingLive(t *testing.T) {
+	b := pkg.Instance{
+		ID:    "222",
+		Type:  "t2.small",
+		State: "running",
+	}
+
+	report := reportMissingLive(b.ID, instanceToState(b), []string{pkg.AttrInstanceType, pkg.AttrInstanceState})
+	assert.Len(t, report.Drifts, 2, "expected 2 drifts")
+	assert.Equal(t, pkg.CommentMissingLive, report.Comment)
+	for _, d := range report.Drifts {
+		assert.Equal(t, "-", d.Expected)
+	}
+}
+
+func TestAlignSecurityGroups(t *testing.T) {
+	live := pkg.Instance{
+		ID:                  "i-1",
+		SecurityGroups:      []string{"web", "default"},
+		VpcSecurityGroupIDs: []string{"sg-2", "sg-1"},
+	}
+	attrs := []string{pkg.AttrSecurityGroups}
+
+	t.Run("should compare by ID ignoring order", func(t *testing.T) {
+		target := pkg.Instance{ID: "i-1", VpcSecurityGroupIDs: []string{"sg-1", "sg-2"}}
+
+		a, b, attrs := alignSecurityGroups(live, target, attrs)
+		report := compareState("i-1", instanceToState(a), instanceToState(b), attrs)
+
+		assert.Empty(t, report.Drifts)
+	})
+
+	t.Run("should report drift in IDs", func(t *testing.T) {
+		target := pkg.Instance{ID: "i-1", SecurityGroups: []string{"web", "default"}, VpcSecurityGroupIDs: []string{"sg-1", "sg-3"}}
+
+		a, b, attrs := alignSecurityGroups(live, target, attrs)
+		report := compareState("i-1", instanceToState(a), instanceToState(b), attrs)
+
+		assert.Len(t, report.Drifts, 1)
+		assert.Equal(t, []string{"sg-1", "sg-2"}, report.Drifts[0].Expected)
+		assert.Equal(t, []string{"sg-1", "sg-3"}, report.Drifts[0].Found)
+	})
+
+	t.Run("should report a drift in IDs once, under the attribute the target sets", func(t *testing.T) {
+		target := pkg.Instance{ID: "i-1", VpcSecurityGroupIDs: []string{"sg-1", "sg-3"}}
+		attrs := []string{pkg.AttrSecurityGroups, pkg.AttrVpcSecurityGroupIDs}
+
+		a, b, attrs := alignSecurityGroups(live, target, attrs)
+		report := compareState("i-1", instanceToState(a), instanceToState(b), attrs)
+
+		assert.Len(t, report.Drifts, 1)
+		assert.Equal(t, pkg.AttrVpcSecurityGroupIDs, report.Drifts[0].Name)
+	})
+
+	t.Run("should not report IDs the target leaves unset when it names groups", func(t *testing.T) {
+		target := pkg.Instance{ID: "i-1", SecurityGroups: []string{"default"}}
+		attrs := []string{pkg.AttrSecurityGroups, pkg.AttrVpcSecurityGroupIDs}
+
+		a, b, attrs := alignSecurityGroups(live, target, attrs)
+		report := compareState("i-1", instanceToState(a), instanceToState(b), attrs)
+
+		assert.Len(t, report.Drifts, 1)
+		assert.Equal(t, pkg.AttrSecurityGroups, report.Drifts[0].Name)
+	})
+
+	t.Run("should fall back to names when target has no IDs", func(t *testing.T) {
+		target := pkg.Instance{ID: "i-1", SecurityGroups: []string{"default", "web"}}
+
+		a, b, attrs := alignSecurityGroups(live, target, attrs)
+		report := compareState("i-1", instanceToState(a), instanceToState(b), attrs)
+
+		assert.Empty(t, report.Drifts)
+	})
+
+	t.Run("should treat groups as known when IDs are known", func(t *testing.T) {
+		target := pkg.Instance{
+			ID:                  "i-1",
+			VpcSecurityGroupIDs: []string{"sg-1"},
+			Unknown:             []string{pkg.AttrSecurityGroups},
+		}
+
+		_, b, _ := alignSecurityGroups(live, target, attrs)
+
+		assert.NotContains(t, b.Unknown, pkg.AttrSecurityGroups)
+		assert.Equal(t, []string{pkg.AttrSecurityGroups}, target.Unknown, "target should not be mutated")
+	})
+}
+
+func TestCompareState_TagsPerKey(t *testing.T) {
+	live := pkg.Instance{ID: "i-1", Tags: map[string]string{"Name": "web", "Owner": "bob", "Team": "platform"}}
+	state := pkg.Instance{ID: "i-1", Tags: map[string]string{"Name": "web", "Owner": "alice", "Env": "prod"}}
+
+	report := compareState(live.ID, instanceToState(live), instanceToState(state), []string{pkg.AttrTags})
+
+	assert.Equal(t, []pkg.AttributeDrift{
+		{Name: "tags.Owner", Expected: "bob", Found: "alice"},
+		{Name: "tags.Team", Expected: "platform", Found: "-"},
+		{Name: "tags.Env", Expected: "-", Found: "prod"},
+	}, report.Drifts)
+	assert.Equal(t, pkg.CommentDriftDetected, report.Comment)
+}
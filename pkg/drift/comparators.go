@@ -0,0 +1,64 @@
+package drift
+
+import (
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/tpriime/ec2diff/pkg"
+)
+
+// comparator reports whether two values of an attribute are semantically equal.
+type comparator func(a, b any) bool
+
+// comparators maps attributes to how their values are compared.
+// Attributes without an entry are compared with cmp.Equal.
+var comparators = map[string]comparator{
+	pkg.AttrInstanceState:       equalFold,
+	pkg.AttrArchitecture:        equalFold,
+	pkg.AttrVirtualizationType:  equalFold,
+	pkg.AttrTags:                equalMap,
+	pkg.AttrSecurityGroups:      equalSet,
+	pkg.AttrVpcSecurityGroupIDs: equalSet,
+}
+
+// equal compares two values of attr using its registered comparator.
+func equal(attr string, a, b any) bool {
+	if compare, ok := comparators[attr]; ok {
+		return compare(a, b)
+	}
+	return cmp.Equal(a, b)
+}
+
+// equalFold compares enum-like strings case-insensitively, e.g. "Running" and "running".
+func equalFold(a, b any) bool {
+	sa, okA := a.(string)
+	sb, okB := b.(string)
+	if !okA || !okB {
+		return cmp.Equal(a, b)
+	}
+	return strings.EqualFold(sa, sb)
+}
+
+// equalMap compares maps treating nil and empty maps as equal.
+func equalMap(a, b any) bool {
+	return cmp.Equal(a, b, cmpopts.EquateEmpty())
+}
+
+// equalSet compares string lists as sets, ignoring order and duplicates.
+func equalSet(a, b any) bool {
+	la, okA := a.([]string)
+	lb, okB := b.([]string)
+	if !okA || !okB {
+		return cmp.Equal(a, b)
+	}
+	return cmp.Equal(toSet(la), toSet(lb), cmpopts.EquateEmpty())
+}
+
+func toSet(list []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(list))
+	for _, v := range list {
+		set[v] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,40 @@
+package drift
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tpriime/ec2diff/pkg"
+)
+
+func TestEqual(t *testing.T) {
+	for name, tc := range map[string]struct {
+		attr  string
+		a, b  any
+		equal bool
+	}{
+		"state ignores case":            {pkg.AttrInstanceState, "Running", "running", true},
+		"state differs":                 {pkg.AttrInstanceState, "stopped", "running", false},
+		"nil tags equal empty tags":     {pkg.AttrTags, map[string]string(nil), map[string]string{}, true},
+		"tags differ":                   {pkg.AttrTags, map[string]string{"a": "1"}, map[string]string{"a": "2"}, false},
+		"security groups ignore order":  {pkg.AttrSecurityGroups, []string{"sg-1", "sg-2"}, []string{"sg-2", "sg-1"}, true},
+		"security groups nil and empty": {pkg.AttrVpcSecurityGroupIDs, []string(nil), []string{}, true},
+		"security groups differ":        {pkg.AttrSecurityGroups, []string{"sg-1"}, []string{"sg-1", "sg-2"}, false},
+		"type is case sensitive":        {pkg.AttrInstanceType, "T2.micro", "t2.micro", false},
+		"default comparison":            {pkg.AttrMonitoring, true, true, true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.equal, equal(tc.attr, tc.a, tc.b))
+		})
+	}
+}
+
+func TestCompareState_Semantic(t *testing.T) {
+	a := pkg.Instance{ID: "i-1", State: "RUNNING", Tags: nil, SecurityGroups: []string{"b", "a"}}
+	b := pkg.Instance{ID: "i-1", State: "running", Tags: map[string]string{}, SecurityGroups: []string{"a", "b"}}
+
+	report := compareState(a.ID, instanceToState(a), instanceToState(b),
+		[]string{pkg.AttrInstanceState, pkg.AttrTags, pkg.AttrSecurityGroups})
+
+	assert.Empty(t, report.Drifts)
+}
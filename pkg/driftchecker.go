@@ -5,4 +5,25 @@ import "context"
 // DriftChecker defines how instances are compared to detect drifts.
 type DriftChecker interface {
 	CheckDrift(ctx context.Context, liveInstances, targetInstances InstanceMap, attributes []string) []Report
+
+	// CheckPages checks every page received on pages until it is closed, sharing the
+	// same workers across pages. Checked pages are sent on the returned channel in the
+	// order they were received, which is closed once the last one is sent.
+	CheckPages(ctx context.Context, pages <-chan Page, targetInstances InstanceMap, attributes []string) <-chan CheckedPage
+
+	// CheckMissingLive reports target instances whose IDs were never seen live.
+	// It is meant to run once, after every live page has been checked.
+	CheckMissingLive(ctx context.Context, seenIDs map[string]struct{}, targetInstances InstanceMap, attributes []string) []Report
+}
+
+// Page is a page of live instances, as numbered by the fetcher.
+type Page struct {
+	Number    int
+	Instances InstanceMap
+}
+
+// CheckedPage holds the reports of the instances of a page.
+type CheckedPage struct {
+	Page
+	Reports []Report
 }
@@ -0,0 +1,344 @@
+package fakeec2
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// Bounds of MaxResults accepted by DescribeInstances.
+const (
+	minResults = 5
+	maxResults = 1000
+)
+
+// Maximum number of filter values accepted in one DescribeInstances call.
+const maxFilterValues = 200
+
+// apiError is an error returned in the EC2 query protocol.
+type apiError struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+type errorResponse struct {
+	XMLName   xml.Name   `xml:"Response"`
+	Errors    []apiError `xml:"Errors>Error"`
+	RequestID string     `xml:"RequestID"`
+}
+
+type describeInstancesResponse struct {
+	XMLName      xml.Name         `xml:"DescribeInstancesResponse"`
+	Xmlns        string           `xml:"xmlns,attr"`
+	RequestID    string           `xml:"requestId"`
+	Reservations []reservationXML `xml:"reservationSet>item"`
+	NextToken    string           `xml:"nextToken,omitempty"`
+}
+
+type reservationXML struct {
+	ReservationID string        `xml:"reservationId"`
+	OwnerID       string        `xml:"ownerId"`
+	Instances     []instanceXML `xml:"instancesSet>item"`
+}
+
+// instanceXML holds the instance fields read by the fetcher, named as in the EC2 query protocol.
+type instanceXML struct {
+	InstanceID         string              `xml:"instanceId"`
+	ImageID            string              `xml:"imageId,omitempty"`
+	InstanceType       string              `xml:"instanceType,omitempty"`
+	KeyName            string              `xml:"keyName,omitempty"`
+	State              *stateXML           `xml:"instanceState,omitempty"`
+	PrivateIP          string              `xml:"privateIpAddress,omitempty"`
+	PublicIP           string              `xml:"ipAddress,omitempty"`
+	SubnetID           string              `xml:"subnetId,omitempty"`
+	VpcID              string              `xml:"vpcId,omitempty"`
+	Architecture       string              `xml:"architecture,omitempty"`
+	VirtualizationType string              `xml:"virtualizationType,omitempty"`
+	Placement          *placementXML       `xml:"placement,omitempty"`
+	Monitoring         *monitoringXML      `xml:"monitoring,omitempty"`
+	IamInstanceProfile *instanceProfileXML `xml:"iamInstanceProfile,omitempty"`
+	Groups             []groupXML          `xml:"groupSet>item"`
+	Tags               []tagXML            `xml:"tagSet>item"`
+}
+
+type stateXML struct {
+	Code int32  `xml:"code"`
+	Name string `xml:"name"`
+}
+
+type placementXML struct {
+	AvailabilityZone string `xml:"availabilityZone"`
+}
+
+type monitoringXML struct {
+	State string `xml:"state"`
+}
+
+type instanceProfileXML struct {
+	Arn string `xml:"arn"`
+}
+
+type groupXML struct {
+	GroupID   string `xml:"groupId"`
+	GroupName string `xml:"groupName"`
+}
+
+type tagXML struct {
+	Key   string `xml:"key"`
+	Value string `xml:"value"`
+}
+
+// match is an instance along with the reservation it belongs to.
+type match struct {
+	reservation *types.Reservation
+	instance    types.Instance
+}
+
+// filter is a DescribeInstances filter with its values compiled from wildcards.
+type filter struct {
+	name   string
+	values []*regexp.Regexp
+}
+
+// describeInstances answers a DescribeInstances request for the given reservations.
+func describeInstances(reservations []types.Reservation, form url.Values) (*describeInstancesResponse, *apiError) {
+	ids := listParam(form, "InstanceId")
+	filters, err := parseFilters(form)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []match
+	found := map[string]bool{}
+	for i := range reservations {
+		for _, inst := range reservations[i].Instances {
+			id := str(inst.InstanceId)
+			if len(ids) > 0 && !slices.Contains(ids, id) {
+				continue
+			}
+			found[id] = true
+			if matchesAll(filters, &reservations[i], inst) {
+				matches = append(matches, match{reservation: &reservations[i], instance: inst})
+			}
+		}
+	}
+	for _, id := range ids {
+		if !found[id] {
+			return nil, &apiError{"InvalidInstanceID.NotFound", fmt.Sprintf("The instance ID '%s' does not exist", id)}
+		}
+	}
+
+	start, end, next, err := paginate(form, len(ids) > 0, len(matches))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &describeInstancesResponse{Xmlns: "http://ec2.amazonaws.com/doc/2016-11-15/", NextToken: next}
+	for _, m := range matches[start:end] {
+		// Instances of a reservation are returned together, even when split across pages
+		if n := len(resp.Reservations); n == 0 || resp.Reservations[n-1].ReservationID != str(m.reservation.ReservationId) {
+			resp.Reservations = append(resp.Reservations, reservationXML{
+				ReservationID: str(m.reservation.ReservationId),
+				OwnerID:       str(m.reservation.OwnerId),
+			})
+		}
+		last := &resp.Reservations[len(resp.Reservations)-1]
+		last.Instances = append(last.Instances, toXML(m.instance))
+	}
+	return resp, nil
+}
+
+// paginate returns the range of the matches to return and the token of the next page, if any.
+func paginate(form url.Values, byID bool, total int) (start, end int, next string, _ *apiError) {
+	end = total
+	if token := form.Get("NextToken"); token != "" {
+		decoded, _ := base64.RawURLEncoding.DecodeString(token)
+		var err error
+		if start, err = strconv.Atoi(string(decoded)); err != nil || start < 0 || start > total {
+			return 0, 0, "", &apiError{"InvalidParameterValue", fmt.Sprintf("Invalid value '%s' for NextToken", token)}
+		}
+	}
+
+	if value := form.Get("MaxResults"); value != "" {
+		if byID {
+			return 0, 0, "", &apiError{"InvalidParameterCombination", "The parameter instancesSet cannot be used with the parameter maxResults"}
+		}
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < minResults || limit > maxResults {
+			return 0, 0, "", &apiError{"InvalidParameterValue", fmt.Sprintf("Value ( %s ) for parameter maxResults is invalid. Expecting a value between %d and %d.", value, minResults, maxResults)}
+		}
+		if start+limit < total {
+			end = start + limit
+			next = base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(end)))
+		}
+	}
+	return start, end, next, nil
+}
+
+// parseFilters reads the Filter.N.Name and Filter.N.Value.M parameters.
+func parseFilters(form url.Values) ([]filter, *apiError) {
+	var filters []filter
+	values := 0
+	for n := 1; form.Has(fmt.Sprintf("Filter.%d.Name", n)); n++ {
+		f := filter{name: form.Get(fmt.Sprintf("Filter.%d.Name", n))}
+		if !supported(f.name) {
+			return nil, &apiError{"InvalidParameterValue", fmt.Sprintf("The filter '%s' is invalid", f.name)}
+		}
+		for _, value := range listParam(form, fmt.Sprintf("Filter.%d.Value", n)) {
+			f.values = append(f.values, wildcard(value))
+		}
+		if values += len(f.values); values > maxFilterValues {
+			return nil, &apiError{"FilterLimitExceeded", fmt.Sprintf("The maximum number of filter values specified on a single call is %d", maxFilterValues)}
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+// supported reports whether the filter name is one the server can evaluate.
+func supported(name string) bool {
+	switch name {
+	case "instance-id", "instance-type", "instance-state-name", "image-id", "key-name",
+		"subnet-id", "vpc-id", "availability-zone", "owner-id", "reservation-id", "tag-key", "tag-value":
+		return true
+	}
+	return strings.HasPrefix(name, "tag:")
+}
+
+// matchesAll reports whether the instance matches every filter, and any value of each.
+func matchesAll(filters []filter, r *types.Reservation, inst types.Instance) bool {
+	for _, f := range filters {
+		if !slices.ContainsFunc(fieldValues(f.name, r, inst), func(v string) bool {
+			return slices.ContainsFunc(f.values, func(re *regexp.Regexp) bool { return re.MatchString(v) })
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldValues returns the values of the instance compared by the named filter.
+func fieldValues(name string, r *types.Reservation, inst types.Instance) []string {
+	var values []string
+	switch name {
+	case "instance-id":
+		values = append(values, str(inst.InstanceId))
+	case "instance-type":
+		values = append(values, string(inst.InstanceType))
+	case "instance-state-name":
+		if inst.State != nil {
+			values = append(values, string(inst.State.Name))
+		}
+	case "image-id":
+		values = append(values, str(inst.ImageId))
+	case "key-name":
+		values = append(values, str(inst.KeyName))
+	case "subnet-id":
+		values = append(values, str(inst.SubnetId))
+	case "vpc-id":
+		values = append(values, str(inst.VpcId))
+	case "availability-zone":
+		if inst.Placement != nil {
+			values = append(values, str(inst.Placement.AvailabilityZone))
+		}
+	case "owner-id":
+		values = append(values, str(r.OwnerId))
+	case "reservation-id":
+		values = append(values, str(r.ReservationId))
+	default:
+		for _, tag := range inst.Tags {
+			switch {
+			case name == "tag-key":
+				values = append(values, str(tag.Key))
+			case name == "tag-value":
+				values = append(values, str(tag.Value))
+			case name == "tag:"+str(tag.Key):
+				values = append(values, str(tag.Value))
+			}
+		}
+	}
+	return values
+}
+
+// wildcard compiles a filter value, in which * matches any run of characters and ? any one.
+func wildcard(value string) *regexp.Regexp {
+	pattern := regexp.QuoteMeta(value)
+	pattern = strings.ReplaceAll(pattern, `\*`, ".*")
+	pattern = strings.ReplaceAll(pattern, `\?`, ".")
+	return regexp.MustCompile("^" + pattern + "$")
+}
+
+// listParam reads the values of a list parameter, sent as prefix.1, prefix.2 and so on.
+func listParam(form url.Values, prefix string) []string {
+	var values []string
+	for n := 1; form.Has(fmt.Sprintf("%s.%d", prefix, n)); n++ {
+		values = append(values, form.Get(fmt.Sprintf("%s.%d", prefix, n)))
+	}
+	return values
+}
+
+// toXML maps an instance to its query protocol representation.
+func toXML(inst types.Instance) instanceXML {
+	out := instanceXML{
+		InstanceID:         str(inst.InstanceId),
+		ImageID:            str(inst.ImageId),
+		InstanceType:       string(inst.InstanceType),
+		KeyName:            str(inst.KeyName),
+		PrivateIP:          str(inst.PrivateIpAddress),
+		PublicIP:           str(inst.PublicIpAddress),
+		SubnetID:           str(inst.SubnetId),
+		VpcID:              str(inst.VpcId),
+		Architecture:       string(inst.Architecture),
+		VirtualizationType: string(inst.VirtualizationType),
+	}
+	if inst.State != nil {
+		out.State = &stateXML{Code: val(inst.State.Code), Name: string(inst.State.Name)}
+	}
+	if inst.Placement != nil {
+		out.Placement = &placementXML{AvailabilityZone: str(inst.Placement.AvailabilityZone)}
+	}
+	if inst.Monitoring != nil {
+		out.Monitoring = &monitoringXML{State: string(inst.Monitoring.State)}
+	}
+	if inst.IamInstanceProfile != nil {
+		out.IamInstanceProfile = &instanceProfileXML{Arn: str(inst.IamInstanceProfile.Arn)}
+	}
+	for _, sg := range inst.SecurityGroups {
+		out.Groups = append(out.Groups, groupXML{GroupID: str(sg.GroupId), GroupName: str(sg.GroupName)})
+	}
+	for _, tag := range inst.Tags {
+		out.Tags = append(out.Tags, tagXML{Key: str(tag.Key), Value: str(tag.Value)})
+	}
+	return out
+}
+
+func writeXML(w http.ResponseWriter, status int, body any) {
+	data, err := xml.Marshal(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/xml;charset=UTF-8")
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	w.Write(data)
+}
+
+func str(ptr *string) string {
+	return val(ptr)
+}
+
+func val[T any](ptr *T) (v T) {
+	if ptr != nil {
+		v = *ptr
+	}
+	return
+}
@@ -0,0 +1,169 @@
+// Package fakeec2 provides an in-process stand-in for the EC2 query API, serving
+// DescribeInstances from fixtures so the AWS SDK client, its paginator and retries
+// can be exercised end to end without network access.
+package fakeec2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// Default owner of the instances added by AddInstances.
+const DefaultOwnerID = "123456789012"
+
+// Server serves DescribeInstances over HTTP from the reservations added to it.
+//
+// Results are paginated by instance with NextToken, as EC2 does, and narrowed down by the
+// InstanceId parameter and the common filters. Any other action is rejected.
+type Server struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	reservations []types.Reservation
+	throttled    int
+	delay        time.Duration
+	requests     []url.Values
+	requestCount int
+}
+
+// NewServer starts a server without instances. It must be closed when done.
+func NewServer() *Server {
+	s := &Server{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// AddInstances adds a reservation of the given instances, owned by DefaultOwnerID.
+func (s *Server) AddInstances(instances ...types.Instance) {
+	s.AddReservations(types.Reservation{OwnerId: ptr(DefaultOwnerID), Instances: instances})
+}
+
+// AddReservations adds reservations as returned by DescribeInstances.
+func (s *Server) AddReservations(reservations ...types.Reservation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range reservations {
+		if r.ReservationId == nil {
+			r.ReservationId = ptr(fmt.Sprintf("r-%017d", len(s.reservations)+1))
+		}
+		s.reservations = append(s.reservations, r)
+	}
+}
+
+// LoadFile adds the reservations of a saved `aws ec2 describe-instances` response.
+func (s *Server) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var out ec2.DescribeInstancesOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	s.AddReservations(out.Reservations...)
+	return nil
+}
+
+// Throttle rejects the next n DescribeInstances requests with RequestLimitExceeded,
+// as EC2 does when the account's request rate is exceeded.
+func (s *Server) Throttle(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.throttled = n
+}
+
+// Delay holds every response for d, e.g. to exceed a client timeout.
+func (s *Server) Delay(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = d
+}
+
+// Requests returns the parameters of every request received, throttled ones included.
+func (s *Server) Requests() []url.Values {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+// SetEnv points the AWS SDK default config at the server for the duration of the test,
+// with static credentials and no instance metadata lookups.
+func (s *Server) SetEnv(t testing.TB) {
+	t.Setenv("AWS_ENDPOINT_URL", s.URL)
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_CONFIG_FILE", os.DevNull)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", os.DevNull)
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		s.writeError(w, http.StatusBadRequest, "MalformedQueryString", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Form)
+	throttled := false
+	if r.Form.Get("Action") == "DescribeInstances" && s.throttled > 0 {
+		s.throttled--
+		throttled = true
+	}
+	reservations := slices.Clone(s.reservations)
+	delay := s.delay
+	s.mu.Unlock()
+
+	select {
+	case <-time.After(delay):
+	case <-r.Context().Done():
+		return
+	}
+
+	switch action := r.Form.Get("Action"); {
+	case action != "DescribeInstances":
+		s.writeError(w, http.StatusBadRequest, "InvalidAction", fmt.Sprintf("The action %s is not valid for this web service.", action))
+	case throttled:
+		s.writeError(w, http.StatusServiceUnavailable, "RequestLimitExceeded", "Request limit exceeded.")
+	default:
+		resp, err := describeInstances(reservations, r.Form)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, err.Code, err.Message)
+			return
+		}
+		resp.RequestID = s.nextRequestID()
+		writeXML(w, http.StatusOK, resp)
+	}
+}
+
+func (s *Server) writeError(w http.ResponseWriter, status int, code, message string) {
+	writeXML(w, status, errorResponse{
+		Errors:    []apiError{{Code: code, Message: message}},
+		RequestID: s.nextRequestID(),
+	})
+}
+
+func (s *Server) nextRequestID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requestCount++
+	return fmt.Sprintf("00000000-0000-0000-0000-%012d", s.requestCount)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
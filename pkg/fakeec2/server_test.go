@@ -0,0 +1,188 @@
+package fakeec2
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// newClient starts a server and returns an SDK client configured through the environment,
+// retrying up to maxAttempts times without waiting.
+func newClient(t *testing.T, maxAttempts int) (*Server, *ec2.Client) {
+	server := NewServer()
+	t.Cleanup(server.Close)
+	server.SetEnv(t)
+
+	cfg, err := config.LoadDefaultConfig(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := ec2.NewFromConfig(cfg, func(o *ec2.Options) {
+		o.Retryer = retry.NewStandard(func(o *retry.StandardOptions) {
+			o.MaxAttempts = maxAttempts
+			o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+		})
+	})
+	return server, client
+}
+
+func instance(id string, tags ...string) types.Instance {
+	inst := types.Instance{
+		InstanceId:   &id,
+		InstanceType: types.InstanceTypeT3Micro,
+		State:        &types.InstanceState{Code: awssdk.Int32(16), Name: types.InstanceStateNameRunning},
+	}
+	for i := 0; i+1 < len(tags); i += 2 {
+		inst.Tags = append(inst.Tags, types.Tag{Key: &tags[i], Value: &tags[i+1]})
+	}
+	return inst
+}
+
+func ids(out *ec2.DescribeInstancesOutput) []string {
+	var ids []string
+	for _, r := range out.Reservations {
+		for _, inst := range r.Instances {
+			ids = append(ids, *inst.InstanceId)
+		}
+	}
+	return ids
+}
+
+func TestServer_Paginates(t *testing.T) {
+	server, client := newClient(t, 1)
+	for i := range 12 {
+		server.AddInstances(instance(fmt.Sprintf("i-%02d", i)))
+	}
+
+	paginator := ec2.NewDescribeInstancesPaginator(client, &ec2.DescribeInstancesInput{MaxResults: awssdk.Int32(5)})
+	var pages [][]string
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(t.Context())
+		assert.NoError(t, err)
+		pages = append(pages, ids(out))
+	}
+
+	assert.Equal(t, [][]string{
+		{"i-00", "i-01", "i-02", "i-03", "i-04"},
+		{"i-05", "i-06", "i-07", "i-08", "i-09"},
+		{"i-10", "i-11"},
+	}, pages)
+	assert.Len(t, server.Requests(), 3)
+	assert.Empty(t, server.Requests()[0].Get("NextToken"))
+	assert.NotEmpty(t, server.Requests()[1].Get("NextToken"))
+}
+
+func TestServer_DecodesInstances(t *testing.T) {
+	server, client := newClient(t, 1)
+	assert.NoError(t, server.LoadFile("../../examples/resources/aws_ec2_response_full.json"))
+
+	out, err := client.DescribeInstances(t.Context(), &ec2.DescribeInstancesInput{})
+
+	assert.NoError(t, err)
+	assert.Len(t, out.Reservations, 1)
+	assert.Equal(t, "534189516062", *out.Reservations[0].OwnerId)
+	inst := out.Reservations[0].Instances[0]
+	assert.Equal(t, "i-0f3d4bc78c78cc67b", *inst.InstanceId)
+	assert.Equal(t, types.InstanceStateNameStopped, inst.State.Name)
+	assert.NotEmpty(t, inst.SecurityGroups)
+	assert.NotNil(t, inst.Placement.AvailabilityZone)
+}
+
+func TestServer_Filters(t *testing.T) {
+	server, client := newClient(t, 1)
+	server.AddInstances(
+		instance("i-1", "Env", "prod", "Team", "web"),
+		instance("i-2", "Env", "staging"),
+		instance("i-3", "Env", "prod-eu"),
+	)
+
+	tests := []struct {
+		filters []types.Filter
+		want    []string
+	}{
+		{[]types.Filter{{Name: awssdk.String("tag:Env"), Values: []string{"prod"}}}, []string{"i-1"}},
+		{[]types.Filter{{Name: awssdk.String("tag:Env"), Values: []string{"prod*"}}}, []string{"i-1", "i-3"}},
+		{[]types.Filter{{Name: awssdk.String("tag-key"), Values: []string{"Team"}}}, []string{"i-1"}},
+		{[]types.Filter{{Name: awssdk.String("instance-id"), Values: []string{"i-2", "i-3", "i-9"}}}, []string{"i-2", "i-3"}},
+		{[]types.Filter{
+			{Name: awssdk.String("instance-state-name"), Values: []string{"running"}},
+			{Name: awssdk.String("tag:Env"), Values: []string{"staging", "prod-??"}},
+		}, []string{"i-2", "i-3"}},
+	}
+
+	for _, tc := range tests {
+		out, err := client.DescribeInstances(t.Context(), &ec2.DescribeInstancesInput{Filters: tc.filters})
+		assert.NoError(t, err)
+		assert.Equal(t, tc.want, ids(out))
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	server, client := newClient(t, 1)
+	server.AddInstances(instance("i-1"))
+
+	tests := map[string]struct {
+		input *ec2.DescribeInstancesInput
+		code  string
+	}{
+		"unknown filter": {
+			&ec2.DescribeInstancesInput{Filters: []types.Filter{{Name: awssdk.String("bogus"), Values: []string{"x"}}}},
+			"InvalidParameterValue",
+		},
+		"too many filter values": {
+			&ec2.DescribeInstancesInput{Filters: []types.Filter{{Name: awssdk.String("instance-id"), Values: make([]string, 201)}}},
+			"FilterLimitExceeded",
+		},
+		"unknown instance": {&ec2.DescribeInstancesInput{InstanceIds: []string{"i-1", "i-9"}}, "InvalidInstanceID.NotFound"},
+		"small page":       {&ec2.DescribeInstancesInput{MaxResults: awssdk.Int32(2)}, "InvalidParameterValue"},
+		"invalid token":    {&ec2.DescribeInstancesInput{NextToken: awssdk.String("bogus")}, "InvalidParameterValue"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := client.DescribeInstances(t.Context(), tc.input)
+
+			var apiErr smithy.APIError
+			if assert.True(t, errors.As(err, &apiErr)) {
+				assert.Equal(t, tc.code, apiErr.ErrorCode())
+			}
+		})
+	}
+}
+
+func TestServer_Throttles(t *testing.T) {
+	t.Run("should fail once retries are exhausted", func(t *testing.T) {
+		server, client := newClient(t, 2)
+		server.AddInstances(instance("i-1"))
+		server.Throttle(2)
+
+		_, err := client.DescribeInstances(t.Context(), &ec2.DescribeInstancesInput{})
+
+		var apiErr smithy.APIError
+		if assert.True(t, errors.As(err, &apiErr)) {
+			assert.Equal(t, "RequestLimitExceeded", apiErr.ErrorCode())
+		}
+		assert.Len(t, server.Requests(), 2)
+	})
+
+	t.Run("should succeed on retry", func(t *testing.T) {
+		server, client := newClient(t, 3)
+		server.AddInstances(instance("i-1"))
+		server.Throttle(2)
+
+		out, err := client.DescribeInstances(t.Context(), &ec2.DescribeInstancesInput{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"i-1"}, ids(out))
+		assert.Len(t, server.Requests(), 3)
+	})
+}
@@ -0,0 +1,169 @@
+// Package hclparser parses Terraform configuration files, extracting aws_instance resource blocks.
+package hclparser
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/tpriime/ec2diff/pkg"
+	"github.com/tpriime/ec2diff/pkg/logger"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/gocty"
+)
+
+// hclParser implements the Parser interface for .hcl and .tf files.
+type hclParser struct{}
+
+// NewHclParser creates a new hclParser instance.
+func NewHclParser() pkg.Parser {
+	return &hclParser{}
+}
+
+// Parse loads a Terraform configuration file and maps its aws_instance blocks by ID.
+//
+// Variables are resolved from `variable` defaults in sibling .tf files and from
+// terraform.tfvars and *.auto.tfvars files in the same directory. Instance IDs are
+// taken from an `id` argument or a matching `import` block. Blocks without either
+// cannot be matched to a live instance, so they are skipped with a warning rather
+// than reported missing.
+func (p hclParser) Parse(filePath string) (pkg.InstanceMap, error) {
+	parser := hclparse.NewParser()
+	body, err := parseBody(parser, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(filePath)
+	vars, err := loadVariables(parser, dir, body)
+	if err != nil {
+		return nil, err
+	}
+	imports, err := loadImports(parser, dir, body)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{"var": cty.ObjectVal(vars)},
+	}
+
+	out := pkg.InstanceMap{}
+	var unresolved []string
+	for _, block := range body.Blocks {
+		if block.Type != "resource" || len(block.Labels) != 2 || block.Labels[0] != "aws_instance" {
+			continue
+		}
+		address := block.Labels[0] + "." + block.Labels[1]
+
+		inst := toInstance(block.Body, ctx)
+		inst.Address = address
+		if inst.ID == "" {
+			id, ok := imports[address]
+			if !ok {
+				logger.Warn(context.Background(), "Skipping aws_instance without an instance ID, add an import block for it",
+					"file", filePath, "address", address)
+				unresolved = append(unresolved, address)
+				continue
+			}
+			inst.ID = id
+		}
+		out[inst.ID] = inst
+	}
+
+	if len(out) == 0 && len(unresolved) > 0 {
+		return nil, fmt.Errorf("no aws_instance resources with an instance ID found in config, add import blocks for: %s",
+			strings.Join(unresolved, ", "))
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%w in config", pkg.ErrNoInstances)
+	}
+	return out, nil
+}
+
+// SupportedTypes returns the file extensions this parser handles.
+func (hclParser) SupportedTypes() []string {
+	return []string{".hcl", ".tf"}
+}
+
+// field describes where a supported HCL argument is decoded into.
+type field struct {
+	ty     cty.Type
+	target any
+}
+
+// toInstance decodes the supported arguments of an aws_instance block.
+// Arguments that are absent or cannot be evaluated statically are marked unknown.
+func toInstance(body *hclsyntax.Body, ctx *hcl.EvalContext) pkg.Instance {
+	inst := pkg.Instance{}
+	fields := map[string]field{
+		pkg.AttrInstanceType:        {cty.String, &inst.Type},
+		pkg.AttrInstanceState:       {cty.String, &inst.State},
+		pkg.AttrKeyName:             {cty.String, &inst.KeyName},
+		pkg.AttrTags:                {cty.Map(cty.String), &inst.Tags},
+		pkg.AttrSecurityGroups:      {cty.List(cty.String), &inst.SecurityGroups},
+		pkg.AttrPublicIP:            {cty.String, &inst.PublicIP},
+		pkg.AttrPrivateIP:           {cty.String, &inst.PrivateIP},
+		pkg.AttrAmi:                 {cty.String, &inst.Ami},
+		pkg.AttrAvailabilityZone:    {cty.String, &inst.AvailabilityZone},
+		pkg.AttrSubnetID:            {cty.String, &inst.SubnetID},
+		pkg.AttrVpcID:               {cty.String, &inst.VpcID},
+		pkg.AttrVpcSecurityGroupIDs: {cty.List(cty.String), &inst.VpcSecurityGroupIDs},
+		pkg.AttrMonitoring:          {cty.Bool, &inst.Monitoring},
+		pkg.AttrArchitecture:        {cty.String, &inst.Architecture},
+		pkg.AttrVirtualizationType:  {cty.String, &inst.VirtualizationType},
+		pkg.AttrIamInstanceProfile:  {cty.String, &inst.IamInstanceProfile},
+	}
+
+	if attr, ok := body.Attributes["id"]; ok {
+		if err := decode(attr.Expr, ctx, cty.String, &inst.ID); err != nil {
+			inst.ID = ""
+		}
+	}
+
+	for name, f := range fields {
+		attr, ok := body.Attributes[name]
+		if !ok || decode(attr.Expr, ctx, f.ty, f.target) != nil {
+			inst.Unknown = append(inst.Unknown, name)
+		}
+	}
+	slices.Sort(inst.Unknown)
+
+	return inst
+}
+
+// decode evaluates expr and stores the result in target, converted to ty.
+// It fails for values that are null or depend on anything other than literals and variables.
+func decode(expr hcl.Expression, ctx *hcl.EvalContext, ty cty.Type, target any) error {
+	val, diags := expr.Value(ctx)
+	if diags.HasErrors() {
+		return diags
+	}
+	if val.IsNull() || !val.IsWhollyKnown() {
+		return fmt.Errorf("value is not known statically")
+	}
+	val, err := convert.Convert(val, ty)
+	if err != nil {
+		return err
+	}
+	return gocty.FromCtyValue(val, target)
+}
+
+// parseBody reads a native syntax HCL file.
+func parseBody(parser *hclparse.Parser, filePath string) (*hclsyntax.Body, error) {
+	file, diags := parser.ParseHCLFile(filePath)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, fmt.Errorf("unsupported HCL syntax in %s", filePath)
+	}
+	return body, nil
+}
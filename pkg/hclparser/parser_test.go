@@ -0,0 +1,199 @@
+package hclparser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tpriime/ec2diff/pkg"
+)
+
+// writeFiles creates the given files in a temporary directory and returns its path.
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestParse_Literals(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"main.tf": `
+resource "aws_instance" "web" {
+  id              = "i-123"
+  instance_type   = "t2.micro"
+  key_name        = "my-key"
+  security_groups = ["default"]
+  tags = {
+    Name = "web"
+  }
+}
+
+resource "aws_s3_bucket" "ignored" {
+  bucket = "ignored"
+}`})
+
+	instances, err := NewHclParser().Parse(filepath.Join(dir, "main.tf"))
+
+	assert.NoError(t, err)
+	assert.Len(t, instances, 1)
+	inst := instances["i-123"]
+	assert.Equal(t, "t2.micro", inst.Type)
+	assert.Equal(t, "my-key", inst.KeyName)
+	assert.Equal(t, []string{"default"}, inst.SecurityGroups)
+	assert.Equal(t, map[string]string{"Name": "web"}, inst.Tags)
+	assert.Contains(t, inst.Unknown, pkg.AttrInstanceState)
+	assert.Contains(t, inst.Unknown, pkg.AttrPublicIP)
+	assert.NotContains(t, inst.Unknown, pkg.AttrTags)
+}
+
+func TestParse_Variables(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"variables.tf": `
+variable "instance_type" {
+  default = "t2.micro"
+}
+variable "key_name" {
+  default = "default-key"
+}
+variable "env" {}`,
+		"terraform.tfvars": `key_name = "tfvars-key"`,
+		"prod.auto.tfvars": `key_name = "prod-key"`,
+		"staging.tfvars":   `key_name = "staging-key"`,
+		"main.tf": `
+import {
+  to = aws_instance.web
+  id = "i-0abc"
+}
+
+resource "aws_instance" "web" {
+  instance_type = var.instance_type
+  key_name      = var.key_name
+  tags = {
+    Env = var.env
+  }
+  public_ip = aws_eip.web.public_ip
+}`,
+	})
+
+	instances, err := NewHclParser().Parse(filepath.Join(dir, "main.tf"))
+
+	assert.NoError(t, err)
+	inst := instances["i-0abc"]
+	assert.Equal(t, "aws_instance.web", inst.Address)
+	assert.Equal(t, "t2.micro", inst.Type)
+	assert.Equal(t, "prod-key", inst.KeyName, "only terraform.tfvars and *.auto.tfvars should be loaded")
+	assert.Contains(t, inst.Unknown, pkg.AttrTags, "tags referencing an unset variable should be unknown")
+	assert.Contains(t, inst.Unknown, pkg.AttrPublicIP, "references to other resources should be unknown")
+	assert.NotContains(t, inst.Unknown, pkg.AttrInstanceType)
+}
+
+func TestParse_VariablesInParsedFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"instance.hcl": `
+variable "type" {
+  default = "t2.large"
+}
+
+resource "aws_instance" "web" {
+  id            = "i-0abc"
+  instance_type = var.type
+}`,
+	})
+
+	instances, err := NewHclParser().Parse(filepath.Join(dir, "instance.hcl"))
+
+	assert.NoError(t, err)
+	inst := instances["i-0abc"]
+	assert.Equal(t, "t2.large", inst.Type, "defaults declared in the parsed file should be used")
+	assert.NotContains(t, inst.Unknown, pkg.AttrInstanceType)
+}
+
+func TestParse_ImportBlock(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"imports.tf": `
+import {
+  to = aws_instance.web
+  id = "i-0abc"
+}`,
+		"main.tf": `
+resource "aws_instance" "web" {
+  instance_type = "t3.small"
+}`,
+	})
+
+	instances, err := NewHclParser().Parse(filepath.Join(dir, "main.tf"))
+
+	assert.NoError(t, err)
+	assert.Contains(t, instances, "i-0abc")
+	assert.Equal(t, "t3.small", instances["i-0abc"].Type)
+}
+
+func TestParse_Example(t *testing.T) {
+	instances, err := NewHclParser().Parse("../../examples/resources/instance.hcl")
+
+	assert.NoError(t, err)
+	inst := instances["i-0f3d4bc78c78cc67b"]
+	assert.Equal(t, "t2.micro", inst.Type)
+	assert.Equal(t, "running", inst.State)
+	assert.Equal(t, "ami-0c94855ba95c71c99", inst.Ami)
+	assert.Equal(t, []string{"sg-0123456789abcdef0"}, inst.VpcSecurityGroupIDs)
+	assert.ElementsMatch(t, []string{
+		pkg.AttrPrivateIP,
+		pkg.AttrAvailabilityZone,
+		pkg.AttrSubnetID,
+		pkg.AttrVpcID,
+		pkg.AttrMonitoring,
+		pkg.AttrArchitecture,
+		pkg.AttrVirtualizationType,
+		pkg.AttrIamInstanceProfile,
+	}, inst.Unknown)
+}
+
+func TestParse_SkipsInstancesWithoutID(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"main.tf": `
+resource "aws_instance" "web" {
+  id            = "i-123"
+  instance_type = "t2.micro"
+}
+
+resource "aws_instance" "db" {
+  instance_type = "t3.large"
+}`})
+
+	instances, err := NewHclParser().Parse(filepath.Join(dir, "main.tf"))
+
+	assert.NoError(t, err)
+	assert.Len(t, instances, 1, "instances without an ID cannot be matched live")
+	assert.Contains(t, instances, "i-123")
+}
+
+func TestParse_NoInstanceIDs(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"main.tf": `
+resource "aws_instance" "web" {
+  instance_type = "t2.micro"
+}`})
+
+	_, err := NewHclParser().Parse(filepath.Join(dir, "main.tf"))
+
+	assert.ErrorContains(t, err, "add import blocks for: aws_instance.web")
+}
+
+func TestParse_NoInstances(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"main.tf": `variable "x" {}`})
+
+	_, err := NewHclParser().Parse(filepath.Join(dir, "main.tf"))
+
+	assert.Error(t, err)
+}
+
+func TestSupportedTypes(t *testing.T) {
+	types := NewHclParser().SupportedTypes()
+
+	assert.Len(t, types, 2)
+	assert.Contains(t, types, ".hcl")
+	assert.Contains(t, types, ".tf")
+}
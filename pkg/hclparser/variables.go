@@ -0,0 +1,121 @@
+package hclparser
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// loadVariables collects variable values visible to the given body of a config in dir.
+//
+// Defaults from `variable` blocks in the body and in *.tf files are overridden by
+// terraform.tfvars, then by *.auto.tfvars files in lexical order, the files Terraform
+// loads on its own. Variables without a static value are left out so that references
+// to them evaluate as unknown.
+func loadVariables(parser *hclparse.Parser, dir string, body *hclsyntax.Body) (map[string]cty.Value, error) {
+	vars := map[string]cty.Value{}
+
+	bodies := []*hclsyntax.Body{body}
+	tfFiles, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range tfFiles {
+		b, err := parseBody(parser, path)
+		if err != nil {
+			return nil, err
+		}
+		if b != body {
+			bodies = append(bodies, b)
+		}
+	}
+	for _, body := range bodies {
+		for _, block := range body.Blocks {
+			if block.Type != "variable" || len(block.Labels) != 1 {
+				continue
+			}
+			def, ok := block.Body.Attributes["default"]
+			if !ok {
+				continue
+			}
+			if val, diags := def.Expr.Value(nil); !diags.HasErrors() {
+				vars[block.Labels[0]] = val
+			}
+		}
+	}
+
+	autoFiles, err := filepath.Glob(filepath.Join(dir, "*.auto.tfvars"))
+	if err != nil {
+		return nil, err
+	}
+	var varFiles []string
+	if _, err := os.Stat(filepath.Join(dir, "terraform.tfvars")); err == nil {
+		varFiles = append(varFiles, filepath.Join(dir, "terraform.tfvars"))
+	}
+	for _, path := range append(varFiles, autoFiles...) {
+		body, err := parseBody(parser, path)
+		if err != nil {
+			return nil, err
+		}
+		for name, attr := range body.Attributes {
+			if val, diags := attr.Expr.Value(nil); !diags.HasErrors() {
+				vars[name] = val
+			}
+		}
+	}
+
+	return vars, nil
+}
+
+// loadImports maps resource addresses to instance IDs declared in `import` blocks
+// of the given body and of the *.tf files in dir.
+func loadImports(parser *hclparse.Parser, dir string, body *hclsyntax.Body) (map[string]string, error) {
+	bodies := []*hclsyntax.Body{body}
+
+	tfFiles, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range tfFiles {
+		b, err := parseBody(parser, path)
+		if err != nil {
+			return nil, err
+		}
+		if b != body {
+			bodies = append(bodies, b)
+		}
+	}
+
+	imports := map[string]string{}
+	for _, b := range bodies {
+		for _, block := range b.Blocks {
+			if block.Type != "import" {
+				continue
+			}
+			to, hasTo := block.Body.Attributes["to"]
+			id, hasID := block.Body.Attributes["id"]
+			if !hasTo || !hasID {
+				continue
+			}
+			traversal, diags := hcl.AbsTraversalForExpr(to.Expr)
+			if diags.HasErrors() || len(traversal) != 2 {
+				continue
+			}
+			val, diags := id.Expr.Value(nil)
+			if diags.HasErrors() || val.IsNull() || val.Type() != cty.String {
+				continue
+			}
+			name, ok := traversal[1].(hcl.TraverseAttr)
+			if !ok {
+				continue
+			}
+			imports[traversal.RootName()+"."+name.Name] = val.AsString()
+		}
+	}
+
+	return imports, nil
+}
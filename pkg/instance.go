@@ -2,22 +2,59 @@ package pkg
 
 // attributes
 const (
-	AttrInstanceType   = "instance_type"
-	AttrInstanceState  = "instance_state"
-	AttrKeyName        = "key_name"
-	AttrTags           = "tags"
-	AttrSecurityGroups = "security_groups"
-	AttrPublicIP       = "public_ip"
+	AttrInstanceType        = "instance_type"
+	AttrInstanceState       = "instance_state"
+	AttrKeyName             = "key_name"
+	AttrTags                = "tags"
+	AttrSecurityGroups      = "security_groups"
+	AttrPublicIP            = "public_ip"
+	AttrPrivateIP           = "private_ip"
+	AttrAmi                 = "ami"
+	AttrAvailabilityZone    = "availability_zone"
+	AttrSubnetID            = "subnet_id"
+	AttrVpcID               = "vpc_id"
+	AttrVpcSecurityGroupIDs = "vpc_security_group_ids"
+	AttrMonitoring          = "monitoring"
+	AttrArchitecture        = "architecture"
+	AttrVirtualizationType  = "virtualization_type"
+	AttrIamInstanceProfile  = "iam_instance_profile"
 )
 
 type Instance struct {
-	ID             string
-	Type           string
-	State          string
-	KeyName        string
-	Tags           map[string]string
-	SecurityGroups []string
-	PublicIP       string
+	ID                  string
+	Type                string
+	State               string
+	KeyName             string
+	Tags                map[string]string
+	SecurityGroups      []string
+	PublicIP            string
+	PrivateIP           string
+	Ami                 string
+	AvailabilityZone    string
+	SubnetID            string
+	VpcID               string
+	VpcSecurityGroupIDs []string
+	Monitoring          bool
+	Architecture        string
+	VirtualizationType  string
+	IamInstanceProfile  string // Instance profile name, not ARN
+
+	// Where a live instance was found. These are not compared.
+	Account string
+	Region  string
+
+	// Terraform resource address of a state instance, e.g. module.web.aws_instance.app["blue"].
+	Address string
+
+	// State file a state instance was read from, when several are merged.
+	Source string
+
+	// States claiming a state instance, when more than one does. The instance is kept from the first.
+	ClaimedBy []string
+
+	// Unknown lists attributes whose value could not be resolved statically.
+	// Drift checks skip them rather than reporting a false drift.
+	Unknown []string
 }
 
 type InstanceMap = map[string]Instance
@@ -0,0 +1,103 @@
+package jsonprinter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/tpriime/ec2diff/pkg"
+)
+
+// document is the top-level JSON output. Reports come first, as they are
+// streamed before the summary is known.
+type document struct {
+	Reports     []pkg.Report     `json:"reports"`
+	Summary     pkg.Summary      `json:"summary"`
+	Partial     string           `json:"partial,omitempty"`      // Why instances were left unchecked, if any were
+	FailedPages []pkg.FailedPage `json:"failed_pages,omitempty"` // Pages left out of a partial report
+}
+
+// summaryLine is the last line of the NDJSON output, after the reports.
+type summaryLine struct {
+	Summary     pkg.Summary      `json:"summary"`
+	Partial     string           `json:"partial,omitempty"`      // Why instances were left unchecked, if any were
+	FailedPages []pkg.FailedPage `json:"failed_pages,omitempty"` // Pages left out of a partial report
+}
+
+// Indentation of the document, and of the reports within its array.
+const (
+	indent       = "  "
+	reportIndent = indent + indent
+)
+
+// jsonPrinter writes all reports as a single JSON document, streaming the
+// reports array as they are generated.
+type jsonPrinter struct {
+	out     io.Writer
+	printed int // Number of reports printed so far
+}
+
+func NewJSONPrinter(output io.Writer) pkg.ReportPrinter {
+	return &jsonPrinter{out: output}
+}
+
+// Begin opens the document and its reports array.
+func (j *jsonPrinter) Begin() {
+	j.printed = 0
+	fmt.Fprintf(j.out, "{\n%s\"reports\": [", indent)
+}
+
+// PrintReport writes the report as the next element of the reports array.
+func (j *jsonPrinter) PrintReport(r pkg.Report) {
+	data, err := json.MarshalIndent(r, reportIndent, indent)
+	if err != nil {
+		return
+	}
+	if j.printed > 0 {
+		fmt.Fprint(j.out, ",")
+	}
+	j.printed++
+	fmt.Fprintf(j.out, "\n%s%s", reportIndent, data)
+}
+
+// End closes the reports array and the document, with the summary, and for a partial
+// run its reason and the pages of live instances that could not be fetched, if any.
+func (j *jsonPrinter) End(summary pkg.Summary, partial string, failed []pkg.FailedPage) {
+	if j.printed > 0 {
+		fmt.Fprintf(j.out, "\n%s", indent)
+	}
+	fmt.Fprint(j.out, "],\n")
+
+	data, _ := json.MarshalIndent(summary, indent, indent)
+	fmt.Fprintf(j.out, "%s\"summary\": %s", indent, data)
+	if partial != "" {
+		data, _ = json.Marshal(partial)
+		fmt.Fprintf(j.out, ",\n%s\"partial\": %s", indent, data)
+	}
+	if len(failed) > 0 {
+		data, _ = json.MarshalIndent(failed, indent, indent)
+		fmt.Fprintf(j.out, ",\n%s\"failed_pages\": %s", indent, data)
+	}
+	fmt.Fprint(j.out, "\n}\n")
+}
+
+// ndjsonPrinter writes one report per line, as they are generated.
+type ndjsonPrinter struct {
+	out io.Writer
+}
+
+func NewNDJSONPrinter(output io.Writer) pkg.ReportPrinter {
+	return &ndjsonPrinter{out: output}
+}
+
+func (n ndjsonPrinter) Begin() {}
+
+func (n ndjsonPrinter) PrintReport(r pkg.Report) {
+	json.NewEncoder(n.out).Encode(r)
+}
+
+// End writes a last line with the summary, and for a partial run its reason and the
+// pages of live instances that could not be fetched, if any. Unlike reports, it has no instance_id.
+func (n ndjsonPrinter) End(summary pkg.Summary, partial string, failed []pkg.FailedPage) {
+	json.NewEncoder(n.out).Encode(summaryLine{Summary: summary, Partial: partial, FailedPages: failed})
+}
@@ -0,0 +1,138 @@
+package jsonprinter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tpriime/ec2diff/pkg"
+)
+
+var reports = []pkg.Report{
+	{
+		InstanceID: "i-1",
+		Comment:    pkg.CommentDriftDetected,
+		Drifts:     []pkg.AttributeDrift{{Name: pkg.AttrInstanceType, Expected: "t2.micro", Found: "t2.small"}},
+	},
+	{InstanceID: "i-2", Comment: pkg.CommentNoDriftDetected, Drifts: []pkg.AttributeDrift{}},
+	{InstanceID: "i-3", Comment: pkg.CommentMissingLive, Drifts: []pkg.AttributeDrift{}},
+}
+
+func TestJSONPrinter_Print(t *testing.T) {
+	var buf bytes.Buffer
+	pkg.PrintAll(NewJSONPrinter(&buf), reports, pkg.Summarize(reports))
+
+	var doc document
+	err := json.Unmarshal(buf.Bytes(), &doc)
+
+	assert.NoError(t, err)
+	assert.Len(t, doc.Reports, 3)
+	assert.Equal(t, pkg.Summary{Total: 3, Drifted: 1, NoDrift: 1, MissingLive: 1}, doc.Summary)
+	assert.Equal(t, "i-1", doc.Reports[0].InstanceID)
+	assert.Equal(t, pkg.AttrInstanceType, doc.Reports[0].Drifts[0].Name)
+}
+
+func TestJSONPrinter_PrintEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	pkg.PrintAll(NewJSONPrinter(&buf), nil, pkg.Summary{})
+
+	assert.Contains(t, buf.String(), `"reports": []`)
+}
+
+func TestNDJSONPrinter_PrintReport(t *testing.T) {
+	var buf bytes.Buffer
+	printer := NewNDJSONPrinter(&buf)
+	printer.Begin()
+	printer.PrintReport(reports[0])
+	assert.Equal(t, 1, strings.Count(buf.String(), "\n"), "reports should be written as they are printed")
+	printer.PrintReport(reports[1])
+	printer.PrintReport(reports[2])
+	failed := []pkg.FailedPage{{Page: 4, Region: "us-east-1", Error: "throttled"}}
+	printer.End(pkg.Summarize(reports), "", failed)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+	assert.Len(t, lines, 4)
+	for i, line := range lines[:3] {
+		var r pkg.Report
+		assert.NoError(t, json.Unmarshal([]byte(line), &r))
+		assert.Equal(t, reports[i].InstanceID, r.InstanceID)
+	}
+	var last summaryLine
+	assert.NoError(t, json.Unmarshal([]byte(lines[3]), &last))
+	assert.Equal(t, pkg.Summarize(reports), last.Summary)
+	assert.Equal(t, failed, last.FailedPages)
+}
+
+func TestJSONPrinter_Streams(t *testing.T) {
+	var buf bytes.Buffer
+	printer := NewJSONPrinter(&buf)
+	printer.Begin()
+	printer.PrintReport(reports[0])
+
+	assert.Contains(t, buf.String(), `"instance_id": "i-1"`, "reports should be written as they are printed")
+	assert.NotContains(t, buf.String(), "summary")
+
+	printer.PrintReport(reports[1])
+	printer.End(pkg.Summary{Total: 2, Drifted: 1, NoDrift: 1}, "", nil)
+
+	var doc document
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Len(t, doc.Reports, 2)
+	assert.Equal(t, pkg.Summary{Total: 2, Drifted: 1, NoDrift: 1}, doc.Summary)
+
+	var indented bytes.Buffer
+	assert.NoError(t, json.Indent(&indented, buf.Bytes(), "", "  "))
+	assert.Equal(t, indented.String(), buf.String(), "the document should be indented as it would be in one go")
+}
+
+func TestJSONPrinter_PrintSuppressed(t *testing.T) {
+	rule := &pkg.SuppressionRule{Attribute: pkg.AttrInstanceState, Reason: "autoscaling"}
+	var buf bytes.Buffer
+	reports := []pkg.Report{{
+		InstanceID: "i-1",
+		Comment:    pkg.CommentDriftDetected,
+		Suppressed: true,
+		Drifts:     []pkg.AttributeDrift{{Name: pkg.AttrInstanceState, Expected: "stopped", Found: "running", Suppressed: true, Rule: rule}},
+	}}
+	pkg.PrintAll(NewJSONPrinter(&buf), reports, pkg.Summarize(reports))
+
+	var doc document
+	err := json.Unmarshal(buf.Bytes(), &doc)
+
+	assert.NoError(t, err)
+	assert.Equal(t, pkg.Summary{Total: 1, Suppressed: 1}, doc.Summary)
+	assert.True(t, doc.Reports[0].Drifts[0].Suppressed)
+	assert.Equal(t, rule, doc.Reports[0].Drifts[0].Rule)
+}
+
+func TestJSONPrinter_PrintPartial(t *testing.T) {
+	var buf bytes.Buffer
+	failed := []pkg.FailedPage{{Page: 2, Region: "us-east-1", Error: "RequestLimitExceeded"}}
+	printer := NewJSONPrinter(&buf)
+	printer.Begin()
+	for _, r := range reports {
+		printer.PrintReport(r)
+	}
+	printer.End(pkg.Summarize(reports), "", failed)
+
+	var doc document
+	err := json.Unmarshal(buf.Bytes(), &doc)
+
+	assert.NoError(t, err)
+	assert.Len(t, doc.Reports, 3)
+	assert.Equal(t, failed, doc.FailedPages)
+
+	buf.Reset()
+	printer.Begin()
+	printer.End(pkg.Summary{}, "stopped after 1 drift", nil)
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, "stopped after 1 drift", doc.Partial)
+
+	buf.Reset()
+	pkg.PrintAll(NewJSONPrinter(&buf), reports, pkg.Summarize(reports))
+	assert.NotContains(t, buf.String(), "failed_pages", "complete reports should not list failed pages")
+	assert.NotContains(t, buf.String(), "partial", "complete reports should not be marked partial")
+}
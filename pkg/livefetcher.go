@@ -3,11 +3,65 @@ package pkg
 import (
 	"context"
 	"errors"
+	"fmt"
 )
 
 var ErrNotFound = errors.New("instance not found")
 
 // PaginatedLiveFetcher defines how instances would be retrieved from a live source.
+//
+// onPageFn is called once per page of instances. Returning false stops the fetch
+// without error, and no further pages are requested.
 type PaginatedLiveFetcher interface {
 	Fetch(ctx context.Context, onPageFn func(page int, instances InstanceMap) bool) error
 }
+
+// IDLiveFetcher is a PaginatedLiveFetcher that can restrict the fetch to known instance IDs.
+type IDLiveFetcher interface {
+	PaginatedLiveFetcher
+	FetchByIDs(ctx context.Context, ids []string, onPageFn func(page int, instances InstanceMap) bool) error
+}
+
+// PageError reports a page of instances that could not be fetched, even after retries.
+//
+// Fetchers return it once they have served every page they still could, so the
+// instances fetched can be reported on as a partial result. Several PageErrors
+// are returned joined with errors.Join.
+type PageError struct {
+	Page   int    // Number of the page that failed, counted per account and region
+	Region string // Region fetched from, if known
+	Err    error
+}
+
+func (e *PageError) Error() string {
+	if e.Region != "" {
+		return fmt.Sprintf("failed to fetch page %d in %s: %v", e.Page, e.Region, e.Err)
+	}
+	return fmt.Sprintf("failed to fetch page %d: %v", e.Page, e.Err)
+}
+
+func (e *PageError) Unwrap() error {
+	return e.Err
+}
+
+// FailedPages returns the pages reported as failed by err. It returns false if err
+// holds any other error, in which case the fetch failed as a whole.
+func FailedPages(err error) ([]FailedPage, bool) {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var pages []FailedPage
+		for _, e := range joined.Unwrap() {
+			failed, ok := FailedPages(e)
+			if !ok {
+				return nil, false
+			}
+			pages = append(pages, failed...)
+		}
+		return pages, true
+	}
+
+	var pageErr *PageError
+	if !errors.As(err, &pageErr) {
+		return nil, false
+	}
+	return []FailedPage{{Page: pageErr.Page, Region: pageErr.Region, Error: pageErr.Err.Error()}}, true
+}
@@ -2,8 +2,10 @@ package logger
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"log/slog"
-	"os"
+	"strings"
 
 	slogctx "github.com/veqryn/slog-context"
 )
@@ -11,17 +13,65 @@ import (
 type Level = slog.Level
 
 const (
+	LevelDebug  Level = slog.LevelDebug
 	LevelInfo   Level = slog.LevelInfo
-	LevelSilent Level = slog.LevelError
+	LevelWarn   Level = slog.LevelWarn
+	LevelError  Level = slog.LevelError
+	LevelSilent Level = slog.LevelError + 4
 )
 
-// Init sets up default logger
-func Init(level Level) {
-	handler := slogctx.NewHandler(
-		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: level}),
-		nil,
-	)
-	slog.SetDefault(slog.New(handler))
+// Format selects how log records are encoded.
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
+
+// Init sets up default logger writing records of at least level to w.
+func Init(w io.Writer, level Level, format Format) {
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler = slog.NewTextHandler(w, opts)
+	if format == FormatJSON {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+
+	slog.SetDefault(slog.New(slogctx.NewHandler(handler, nil)))
+}
+
+// ParseLevel maps a level name (debug, info, warn, error or silent) to a Level.
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	case "silent":
+		return LevelSilent, nil
+	default:
+		return 0, fmt.Errorf("log level '%s' not supported. Supported levels: [debug info warn error silent]", name)
+	}
+}
+
+// ParseFormat maps a format name (text or json) to a Format.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case FormatText, FormatJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("log format '%s' not supported. Supported formats: [text json]", name)
+	}
+}
+
+// NewContext returns a copy of ctx carrying the default logger, in place of any
+// logger it already carries. Loggers stored in ctx are not updated by Init.
+func NewContext(ctx context.Context) context.Context {
+	return slogctx.NewCtx(ctx, slog.Default())
 }
 
 func With(ctx context.Context, keyvals ...any) context.Context {
@@ -27,24 +27,71 @@ func (m *MockParser) SupportedTypes() []string {
 // MockLiveFetcher implements pkg.LiveFetcher for testing
 type MockLiveFetcher struct {
 	Instances pkg.InstanceMap
-	Err       error
+	Err       error // Returned instead of serving Instances, or after serving Pages
+
+	// Pages, if set, are served one by one instead of Instances
+	Pages []pkg.InstanceMap
+	// Number of pages served
+	Served int
+
+	// IDs received by FetchByIDs
+	FetchedIDs []string
 }
 
 func (m *MockLiveFetcher) Fetch(_ context.Context, onPageFn func(page int, instances pkg.InstanceMap) bool) error {
+	if m.Err != nil && m.Pages == nil {
+		return m.Err
+	}
+	if m.Pages == nil {
+		m.Served++
+		onPageFn(1, m.Instances)
+		return nil
+	}
+	for i, page := range m.Pages {
+		m.Served++
+		if !onPageFn(i+1, page) {
+			break
+		}
+	}
+	return m.Err
+}
+
+func (m *MockLiveFetcher) FetchByIDs(_ context.Context, ids []string, onPageFn func(page int, instances pkg.InstanceMap) bool) error {
 	if m.Err != nil {
 		return m.Err
 	}
-	onPageFn(1, m.Instances)
+	m.FetchedIDs = ids
+	instances := pkg.InstanceMap{}
+	for _, id := range ids {
+		if inst, ok := m.Instances[id]; ok {
+			instances[id] = inst
+		}
+	}
+	onPageFn(1, instances)
 	return nil
 }
 
 // MockReportPrinter implements pkg.ReportPrinter for testing
 type MockReportPrinter struct {
 	Output []pkg.Report
+
+	// Set once End is called
+	Ended   bool
+	Summary pkg.Summary
+	Partial string
+	Failed  []pkg.FailedPage
+}
+
+func (m *MockReportPrinter) Begin() {
+	m.Output = nil
+}
+
+func (m *MockReportPrinter) PrintReport(report pkg.Report) {
+	m.Output = append(m.Output, report)
 }
 
-func (m *MockReportPrinter) Print(reports []pkg.Report) {
-	m.Output = reports
+func (m *MockReportPrinter) End(summary pkg.Summary, partial string, failed []pkg.FailedPage) {
+	m.Ended, m.Summary, m.Partial, m.Failed = true, summary, partial, failed
 }
 
 // MockDriftChecker implements pkg.DriftChecker for testing
@@ -53,3 +100,36 @@ type MockDriftChecker struct{}
 func (m *MockDriftChecker) CheckDrift(ctx context.Context, live, state pkg.InstanceMap, attrs []string) []pkg.Report {
 	return []pkg.Report{{InstanceID: "i-abc", Drifts: nil}}
 }
+
+func (m *MockDriftChecker) CheckPages(ctx context.Context, pages <-chan pkg.Page, state pkg.InstanceMap, attrs []string) <-chan pkg.CheckedPage {
+	out := make(chan pkg.CheckedPage)
+	go func() {
+		defer close(out)
+		for page := range pages {
+			out <- pkg.CheckedPage{Page: page, Reports: m.CheckDrift(ctx, page.Instances, state, attrs)}
+		}
+	}()
+	return out
+}
+
+func (m *MockDriftChecker) CheckMissingLive(ctx context.Context, seen map[string]struct{}, state pkg.InstanceMap, attrs []string) []pkg.Report {
+	return nil
+}
+
+// MockStateSource implements pkg.StateSource for testing
+type MockStateSource struct {
+	Data    []byte
+	Schemes []string
+	Err     error
+}
+
+func (m *MockStateSource) Read(_ context.Context, uri string) ([]byte, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	return m.Data, nil
+}
+
+func (m *MockStateSource) SupportedSchemes() []string {
+	return m.Schemes
+}
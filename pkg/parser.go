@@ -1,5 +1,10 @@
 package pkg
 
+import "errors"
+
+// ErrNoInstances is returned by parsers for files that hold no aws_instance resources.
+var ErrNoInstances = errors.New("no aws_instance resources found")
+
 // Parser defines how to extract instances from supported config files (e.g. HCL, JSON).
 type Parser interface {
 
@@ -11,3 +16,12 @@ type Parser interface {
 	// SupportedTypes lists the file extensions this parser can handle.
 	SupportedTypes() []string
 }
+
+// DataParser is a Parser that can also decode content that is already loaded,
+// such as state read from a remote backend.
+type DataParser interface {
+	Parser
+
+	// ParseData decodes the given content and returns instances by ID.
+	ParseData(data []byte) (InstanceMap, error)
+}
@@ -0,0 +1,59 @@
+// Package remotestate reads Terraform state from remote backends without taking their locks.
+package remotestate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/tpriime/ec2diff/pkg"
+)
+
+// httpSource reads state from an HTTP backend, such as GitLab's managed Terraform state.
+type httpSource struct {
+	client   *http.Client
+	username string
+	password string
+}
+
+// NewHTTPSource creates a source for http(s) URIs.
+// Requests use basic auth when a username is given, as Terraform's http backend does.
+func NewHTTPSource(client *http.Client, username, password string) pkg.StateSource {
+	return &httpSource{client: client, username: username, password: password}
+}
+
+// Read fetches the state with a plain GET, which never locks it.
+func (h httpSource) Read(ctx context.Context, uri string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	if h.username != "" {
+		req.SetBasicAuth(h.username, h.password)
+	}
+	return do(h.client, req)
+}
+
+// SupportedSchemes returns the URI schemes this source handles.
+func (httpSource) SupportedSchemes() []string {
+	return []string{"http", "https"}
+}
+
+// do sends req and returns the response body, failing on any non-200 status.
+func do(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNoContent, http.StatusNotFound:
+		return nil, fmt.Errorf("no state found at %s", req.URL.Redacted())
+	default:
+		return nil, fmt.Errorf("unexpected status %s from %s", resp.Status, req.URL.Redacted())
+	}
+}
@@ -0,0 +1,51 @@
+package remotestate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const state = `{"resources":[{"type":"aws_instance","instances":[{"attributes":{"id":"i-123"}}]}]}`
+
+func TestHTTPSource_Read(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if r.Method != http.MethodGet || !ok || user != "gitlab-ci-token" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(state))
+	}))
+	defer server.Close()
+
+	data, err := NewHTTPSource(server.Client(), "gitlab-ci-token", "secret").Read(t.Context(), server.URL+"/state/prod")
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, state, string(data))
+}
+
+func TestHTTPSource_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+	source := NewHTTPSource(server.Client(), "", "")
+
+	_, err := source.Read(t.Context(), server.URL+"/empty")
+	assert.ErrorContains(t, err, "no state found")
+
+	_, err = source.Read(t.Context(), server.URL+"/private")
+	assert.ErrorContains(t, err, "401")
+}
+
+func TestHTTPSource_SupportedSchemes(t *testing.T) {
+	assert.Equal(t, []string{"http", "https"}, NewHTTPSource(nil, "", "").SupportedSchemes())
+}
@@ -0,0 +1,85 @@
+package remotestate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/tpriime/ec2diff/pkg"
+)
+
+// s3API defines the subset of S3 client methods used.
+type s3API interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// s3Source reads state stored by Terraform's s3 backend.
+type s3Source struct {
+	optFns []func(*s3.Options)
+
+	once   sync.Once
+	client s3API
+	err    error // Error initializing the client, returned by every read
+}
+
+// NewS3Source creates a source whose S3 client is initialized from the default AWS
+// config on the first read, so that runs without S3 states never load it.
+// optFns customize the client, e.g. to point it at an S3-compatible endpoint.
+func NewS3Source(optFns ...func(*s3.Options)) pkg.StateSource {
+	return &s3Source{optFns: optFns}
+}
+
+// init creates the S3 client, once.
+func (s *s3Source) init(ctx context.Context) (s3API, error) {
+	s.once.Do(func() {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			s.err = fmt.Errorf("unable to load AWS config: %w", err)
+			return
+		}
+		s.client = s3.NewFromConfig(cfg, s.optFns...)
+	})
+	return s.client, s.err
+}
+
+// Read fetches the object at s3://bucket/key. A region query parameter
+// (s3://bucket/key?region=eu-west-1) overrides the configured region.
+//
+// The DynamoDB lock table used by the backend is never touched.
+func (s *s3Source) Read(ctx context.Context, uri string) ([]byte, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	bucket, key := u.Host, strings.TrimPrefix(u.Path, "/")
+	if bucket == "" || key == "" {
+		return nil, fmt.Errorf("invalid S3 URI '%s'. Expected s3://bucket/key", uri)
+	}
+
+	var optFns []func(*s3.Options)
+	if region := u.Query().Get("region"); region != "" {
+		optFns = append(optFns, func(o *s3.Options) { o.Region = region })
+	}
+
+	client, err := s.init(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: &bucket, Key: &key}, optFns...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state object: %w", err)
+	}
+	defer out.Body.Close()
+
+	return io.ReadAll(out.Body)
+}
+
+// SupportedSchemes returns the URI schemes this source handles.
+func (*s3Source) SupportedSchemes() []string {
+	return []string{"s3"}
+}
@@ -0,0 +1,67 @@
+package remotestate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+// newS3Source returns a source backed by an S3-compatible stand-in serving objects by bucket/key path.
+func newS3Source(t *testing.T, objects map[string]string) (*s3Source, *[]string) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		body, ok := objects[r.URL.Path]
+		if r.Method != http.MethodGet || !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`<Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`))
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	source := NewS3Source(func(o *s3.Options) {
+		o.BaseEndpoint = &server.URL
+		o.UsePathStyle = true
+	})
+	return source.(*s3Source), &requests
+}
+
+func TestS3Source_Read(t *testing.T) {
+	source, requests := newS3Source(t, map[string]string{"/tf-state/env/prod/terraform.tfstate": state})
+
+	data, err := source.Read(t.Context(), "s3://tf-state/env/prod/terraform.tfstate?region=eu-west-1")
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, state, string(data))
+	assert.Equal(t, []string{"GET /tf-state/env/prod/terraform.tfstate"}, *requests, "only the state object should be read")
+}
+
+func TestS3Source_Errors(t *testing.T) {
+	source, _ := newS3Source(t, map[string]string{})
+
+	_, err := source.Read(t.Context(), "s3://tf-state/missing.tfstate")
+	assert.ErrorContains(t, err, "NoSuchKey")
+
+	_, err = source.Read(t.Context(), "s3://tf-state")
+	assert.ErrorContains(t, err, "invalid S3 URI")
+}
+
+func TestS3Source_LoadsConfigOnRead(t *testing.T) {
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_PROFILE", "missing")
+
+	source := NewS3Source()
+
+	_, err := source.Read(t.Context(), "s3://tf-state/terraform.tfstate")
+	assert.ErrorContains(t, err, "unable to load AWS config", "a broken AWS config should only fail S3 reads")
+}
@@ -0,0 +1,119 @@
+package remotestate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/tpriime/ec2diff/pkg"
+)
+
+// DefaultTFCAddress is the address of HCP Terraform, formerly Terraform Cloud.
+const DefaultTFCAddress = "https://app.terraform.io"
+
+// tfcSource reads the current state version of a Terraform Cloud or Enterprise workspace.
+type tfcSource struct {
+	client  *http.Client
+	address string
+	token   string
+}
+
+// NewTFCSource creates a source for tfc://organization/workspace URIs,
+// calling the API at address with the given token. The token is only sent to
+// address, not to state download URLs on other hosts.
+func NewTFCSource(client *http.Client, address, token string) pkg.StateSource {
+	return &tfcSource{client: client, address: strings.TrimSuffix(address, "/"), token: token}
+}
+
+// TFCTokenFromEnv returns the API token for address, as the Terraform CLI would find it:
+// TF_TOKEN_<host> with dots replaced by underscores, then TFE_TOKEN.
+func TFCTokenFromEnv(address string) string {
+	if u, err := url.Parse(address); err == nil && u.Host != "" {
+		if token := os.Getenv("TF_TOKEN_" + strings.ReplaceAll(u.Hostname(), ".", "_")); token != "" {
+			return token
+		}
+	}
+	return os.Getenv("TFE_TOKEN")
+}
+
+// Read resolves the workspace, then downloads its current state version.
+// Only read endpoints are used, so the workspace is never locked.
+func (t tfcSource) Read(ctx context.Context, uri string) ([]byte, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	org, workspace := u.Host, strings.Trim(u.Path, "/")
+	if org == "" || workspace == "" || strings.Contains(workspace, "/") {
+		return nil, fmt.Errorf("invalid workspace URI '%s'. Expected tfc://organization/workspace", uri)
+	}
+
+	var ws struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	path := fmt.Sprintf("/api/v2/organizations/%s/workspaces/%s", url.PathEscape(org), url.PathEscape(workspace))
+	if err := t.getJSON(ctx, t.address+path, &ws); err != nil {
+		return nil, fmt.Errorf("failed to find workspace: %w", err)
+	}
+
+	var sv struct {
+		Data struct {
+			Attributes struct {
+				DownloadURL string `json:"hosted-state-download-url"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	path = fmt.Sprintf("/api/v2/workspaces/%s/current-state-version", url.PathEscape(ws.Data.ID))
+	if err := t.getJSON(ctx, t.address+path, &sv); err != nil {
+		return nil, fmt.Errorf("failed to find current state version: %w", err)
+	}
+
+	req, err := t.newRequest(ctx, sv.Data.Attributes.DownloadURL)
+	if err != nil {
+		return nil, err
+	}
+	return do(t.client, req)
+}
+
+// SupportedSchemes returns the URI schemes this source handles.
+func (tfcSource) SupportedSchemes() []string {
+	return []string{"tfc"}
+}
+
+// getJSON calls an API endpoint and decodes its JSON:API response into v.
+func (t tfcSource) getJSON(ctx context.Context, endpoint string, v any) error {
+	req, err := t.newRequest(ctx, endpoint)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.api+json")
+
+	data, err := do(t.client, req)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (t tfcSource) newRequest(ctx context.Context, endpoint string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if t.token != "" && t.isAPI(req.URL) {
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	}
+	return req, nil
+}
+
+// isAPI reports whether u has the scheme and host of the configured address.
+func (t tfcSource) isAPI(u *url.URL) bool {
+	api, err := url.Parse(t.address)
+	return err == nil && u.Scheme == api.Scheme && u.Host == api.Host
+}
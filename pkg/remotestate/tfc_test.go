@@ -0,0 +1,96 @@
+package remotestate
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTFCServer stands in for the Terraform Cloud API with a single workspace.
+func newTFCServer(t *testing.T, token string) *httptest.Server {
+	mux := http.NewServeMux()
+	var server *httptest.Server
+
+	mux.HandleFunc("GET /api/v2/organizations/acme/workspaces/prod", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"id":"ws-123","type":"workspaces"}}`)
+	})
+	mux.HandleFunc("GET /api/v2/workspaces/ws-123/current-state-version", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data":{"id":"sv-1","attributes":{"hosted-state-download-url":"%s/download/sv-1"}}}`, server.URL)
+	})
+	mux.HandleFunc("GET /download/sv-1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, state)
+	})
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestTFCSource_Read(t *testing.T) {
+	server := newTFCServer(t, "secret")
+
+	data, err := NewTFCSource(server.Client(), server.URL, "secret").Read(t.Context(), "tfc://acme/prod")
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, state, string(data))
+}
+
+func TestTFCSource_Errors(t *testing.T) {
+	server := newTFCServer(t, "secret")
+
+	_, err := NewTFCSource(server.Client(), server.URL, "wrong").Read(t.Context(), "tfc://acme/prod")
+	assert.ErrorContains(t, err, "failed to find workspace")
+
+	_, err = NewTFCSource(server.Client(), server.URL, "secret").Read(t.Context(), "tfc://acme/staging")
+	assert.ErrorContains(t, err, "no state found")
+
+	for _, uri := range []string{"tfc://acme", "tfc:///prod", "tfc://acme/prod/extra"} {
+		_, err = NewTFCSource(server.Client(), server.URL, "secret").Read(t.Context(), uri)
+		assert.ErrorContains(t, err, "invalid workspace URI", uri)
+	}
+}
+
+func TestTFCSource_Read_TokenOnlySentToAPI(t *testing.T) {
+	var downloadAuth []string
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloadAuth = append(downloadAuth, r.Header.Get("Authorization"))
+		fmt.Fprint(w, state)
+	}))
+	t.Cleanup(storage.Close)
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/organizations/acme/workspaces/prod":
+			fmt.Fprint(w, `{"data":{"id":"ws-123","type":"workspaces"}}`)
+		case "/api/v2/workspaces/ws-123/current-state-version":
+			fmt.Fprintf(w, `{"data":{"id":"sv-1","attributes":{"hosted-state-download-url":"%s/sv-1"}}}`, storage.URL)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(api.Close)
+
+	data, err := NewTFCSource(api.Client(), api.URL, "secret").Read(t.Context(), "tfc://acme/prod")
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, state, string(data))
+	assert.Equal(t, []string{""}, downloadAuth, "the token should not be sent to another host")
+}
+
+func TestTFCTokenFromEnv(t *testing.T) {
+	t.Setenv("TFE_TOKEN", "fallback")
+	t.Setenv("TF_TOKEN_app_terraform_io", "")
+	assert.Equal(t, "fallback", TFCTokenFromEnv(DefaultTFCAddress))
+
+	t.Setenv("TF_TOKEN_app_terraform_io", "host-token")
+	assert.Equal(t, "host-token", TFCTokenFromEnv(DefaultTFCAddress))
+}
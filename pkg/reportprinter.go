@@ -4,23 +4,125 @@ const (
 	CommentDriftDetected   = "Drifts detected"
 	CommentNoDriftDetected = "No drifts detected"
 	CommentMissingState    = "Missing state"
+	CommentMissingLive     = "Missing live"
+)
+
+// Changes between two runs, set when comparing them
+const (
+	ChangeNew       = "new"
+	ChangeResolved  = "resolved"
+	ChangeChanged   = "changed"
+	ChangeUnchanged = "unchanged"
 )
 
 // ReportPrinter defines how reports would be printed.
+//
+// Reports are streamed: Begin is called once, PrintReport as each report is
+// generated, and End once every report is printed, along with their summary.
+// If the run left instances unchecked, partial gives the reason, and failed lists
+// the pages of live instances that could not be fetched, if any.
 type ReportPrinter interface {
-	Print(reports []Report)
+	Begin()
+	PrintReport(report Report)
+	End(summary Summary, partial string, failed []FailedPage)
+}
+
+// PrintAll prints reports that are all known upfront with p, ending with their summary.
+func PrintAll(p ReportPrinter, reports []Report, summary Summary) {
+	p.Begin()
+	for _, r := range reports {
+		p.PrintReport(r)
+	}
+	p.End(summary, "", nil)
+}
+
+// FailedPage is a page of live instances left out of a partial report.
+type FailedPage struct {
+	Page   int    `json:"page"`
+	Region string `json:"region,omitempty"`
+	Error  string `json:"error"`
 }
 
 // Report captures drift for one instance
 type Report struct {
 	InstanceID string           `json:"instance_id"`
+	Address    string           `json:"address,omitempty"`
+	Sources    []string         `json:"sources,omitempty"` // State files the instance was read from
+	Account    string           `json:"account,omitempty"`
+	Region     string           `json:"region,omitempty"`
 	Drifts     []AttributeDrift `json:"drifts"`
 	Comment    string           `json:"comment"`
+	Conflict   bool             `json:"conflict,omitempty"`   // Whether several states claim the instance, listed in Sources
+	Suppressed bool             `json:"suppressed,omitempty"` // Whether every drift is suppressed
+	Change     string           `json:"change,omitempty"`     // How the report changed since an earlier run
 }
 
 // AttributeDrift describes an attribute mismatch
 type AttributeDrift struct {
-	Name     string `json:"name"`
-	Expected any    `json:"expected"`
-	Found    any    `json:"found"`
+	Name       string           `json:"name"`
+	Expected   any              `json:"expected"`
+	Found      any              `json:"found"`
+	Suppressed bool             `json:"suppressed,omitempty"`
+	Rule       *SuppressionRule `json:"rule,omitempty"`   // The rule suppressing the drift
+	Change     string           `json:"change,omitempty"` // How the drift changed since an earlier run
+}
+
+// SuppressionRule accepts known drift, so that it is not counted as new.
+// Every selector that is set must match for a drift to be suppressed.
+type SuppressionRule struct {
+	InstanceID string            `json:"instance_id,omitempty" yaml:"instance_id" hcl:"instance_id,optional"`
+	Address    string            `json:"address,omitempty" yaml:"address" hcl:"address,optional"`
+	Tags       map[string]string `json:"tags,omitempty" yaml:"tags" hcl:"tags,optional"` // Tags the instance must have
+	Attribute  string            `json:"attribute,omitempty" yaml:"attribute" hcl:"attribute,optional"`
+	TagKeys    []string          `json:"tag_keys,omitempty" yaml:"tag_keys" hcl:"tag_keys,optional"` // Globs of the tag keys allowed to drift
+	Expires    string            `json:"expires,omitempty" yaml:"expires" hcl:"expires,optional"`    // Date (YYYY-MM-DD) after which the rule no longer applies
+	Reason     string            `json:"reason,omitempty" yaml:"reason" hcl:"reason,optional"`
+}
+
+// Summary aggregates reports by outcome
+type Summary struct {
+	Total        int `json:"total"`
+	Drifted      int `json:"drifted"`
+	NoDrift      int `json:"no_drift"`
+	MissingState int `json:"missing_state"`
+	MissingLive  int `json:"missing_live"`
+	Conflicts    int `json:"conflicts"`
+	Suppressed   int `json:"suppressed"`
+
+	// Set when comparing two runs, whose reports are counted by change rather than by comment
+	Changes *ChangeSummary `json:"changes,omitempty"`
+}
+
+// ChangeSummary counts the reports of a comparison between two runs by how they changed.
+type ChangeSummary struct {
+	New       int `json:"new"`
+	Resolved  int `json:"resolved"`
+	Changed   int `json:"changed"`
+	Unchanged int `json:"unchanged"`
+}
+
+// Summarize counts reports by their comment. Conflicts are counted apart, as
+// a conflicted instance is also reported as drifted, missing or not.
+func Summarize(reports []Report) Summary {
+	s := Summary{Total: len(reports)}
+	for _, r := range reports {
+		if r.Conflict {
+			s.Conflicts++
+		}
+		if r.Suppressed {
+			s.Suppressed++
+			continue
+		}
+		switch r.Comment {
+		case CommentDriftDetected:
+			s.Drifted++
+		case CommentNoDriftDetected:
+			s.NoDrift++
+		case CommentMissingState:
+			s.MissingState++
+		case CommentMissingLive:
+			s.MissingLive++
+		}
+	}
+	return s
 }
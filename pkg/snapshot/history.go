@@ -0,0 +1,92 @@
+package snapshot
+
+import (
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/tpriime/ec2diff/pkg"
+)
+
+// InstanceHistory lists the periods an instance was drifting, oldest first.
+type InstanceHistory struct {
+	InstanceID string   `json:"instance_id"`
+	Address    string   `json:"address,omitempty"`
+	Periods    []Period `json:"periods"`
+}
+
+// Period is a span of consecutive runs in which an instance drifted.
+type Period struct {
+	Since    time.Time  `json:"since"`              // Start of the first run the drift was found in
+	Resolved *time.Time `json:"resolved,omitempty"` // Start of the first run it was no longer found in, if any
+	Comment  string     `json:"comment"`            // Comment of the latest report in the period
+	Drifts   []string   `json:"drifts"`             // Attributes that drifted at any point in the period
+}
+
+// History derives, for each instance that ever drifted, when its drift first
+// appeared and when it was resolved. Runs must be ordered oldest first.
+//
+// Drift counts as resolved once a later run reports the instance without new
+// drift, or no longer reports it at all. Suppressed drift is not counted. As a
+// partial run may not have checked the instances it does not report, their
+// drift is only resolved once reported without it.
+func History(runs []Run) []InstanceHistory {
+	histories := map[string]*InstanceHistory{}
+	open := map[string]*Period{}
+
+	for _, run := range runs {
+		drifting := map[string]struct{}{}
+		reported := map[string]struct{}{}
+		for _, r := range run.Reports {
+			reported[r.InstanceID] = struct{}{}
+			if r.Comment == pkg.CommentNoDriftDetected || r.Suppressed {
+				continue
+			}
+			drifting[r.InstanceID] = struct{}{}
+
+			h, ok := histories[r.InstanceID]
+			if !ok {
+				h = &InstanceHistory{InstanceID: r.InstanceID}
+				histories[r.InstanceID] = h
+			}
+			if r.Address != "" {
+				h.Address = r.Address
+			}
+
+			p, ok := open[r.InstanceID]
+			if !ok {
+				h.Periods = append(h.Periods, Period{Since: run.StartedAt})
+				p = &h.Periods[len(h.Periods)-1]
+				open[r.InstanceID] = p
+			}
+			p.Comment = r.Comment
+			for _, d := range r.Drifts {
+				if !d.Suppressed && !slices.Contains(p.Drifts, d.Name) {
+					p.Drifts = append(p.Drifts, d.Name)
+				}
+			}
+		}
+
+		// Close the periods of instances no longer drifting
+		for id, p := range open {
+			if _, ok := reported[id]; !ok && run.Partial != "" {
+				continue
+			}
+			if _, ok := drifting[id]; !ok {
+				resolved := run.StartedAt
+				p.Resolved = &resolved
+				delete(open, id)
+			}
+		}
+	}
+
+	out := make([]InstanceHistory, 0, len(histories))
+	for _, id := range slices.Sorted(maps.Keys(histories)) {
+		h := histories[id]
+		for i := range h.Periods {
+			slices.Sort(h.Periods[i].Drifts)
+		}
+		out = append(out, *h)
+	}
+	return out
+}
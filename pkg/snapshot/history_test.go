@@ -0,0 +1,68 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tpriime/ec2diff/pkg"
+)
+
+func day(d int) time.Time {
+	return time.Date(2026, 6, d, 0, 0, 0, 0, time.UTC)
+}
+
+func drifted(id string, attrs ...string) pkg.Report {
+	r := pkg.Report{InstanceID: id, Comment: pkg.CommentDriftDetected}
+	for _, attr := range attrs {
+		r.Drifts = append(r.Drifts, pkg.AttributeDrift{Name: attr})
+	}
+	return r
+}
+
+func TestHistory(t *testing.T) {
+	clean := func(id string) pkg.Report { return pkg.Report{InstanceID: id, Comment: pkg.CommentNoDriftDetected} }
+	suppressed := drifted("i-3", pkg.AttrInstanceState)
+	suppressed.Suppressed = true
+
+	runs := []Run{
+		{StartedAt: day(1), Reports: []pkg.Report{clean("i-1"), drifted("i-2", "tags.Owner"), suppressed}},
+		{StartedAt: day(2), Reports: []pkg.Report{drifted("i-1", pkg.AttrInstanceType), drifted("i-2", "tags.Team")}},
+		{StartedAt: day(3), Reports: []pkg.Report{clean("i-1"), drifted("i-2", "tags.Owner")}},
+		{StartedAt: day(4), Reports: []pkg.Report{drifted("i-1", pkg.AttrInstanceType)}},
+	}
+
+	history := History(runs)
+
+	resolved2, resolved4 := day(3), day(4)
+	assert.Equal(t, []InstanceHistory{
+		{InstanceID: "i-1", Periods: []Period{
+			{Since: day(2), Resolved: &resolved2, Comment: pkg.CommentDriftDetected, Drifts: []string{pkg.AttrInstanceType}},
+			{Since: day(4), Comment: pkg.CommentDriftDetected, Drifts: []string{pkg.AttrInstanceType}},
+		}},
+		{InstanceID: "i-2", Periods: []Period{
+			{Since: day(1), Resolved: &resolved4, Comment: pkg.CommentDriftDetected, Drifts: []string{"tags.Owner", "tags.Team"}},
+		}},
+	}, history, "suppressed drift should not be tracked")
+}
+
+func TestHistory_PartialRun(t *testing.T) {
+	clean := pkg.Report{InstanceID: "i-2", Comment: pkg.CommentNoDriftDetected}
+	runs := []Run{
+		{StartedAt: day(1), Reports: []pkg.Report{drifted("i-1", pkg.AttrInstanceType), drifted("i-2", pkg.AttrTags)}},
+		{StartedAt: day(2), Partial: "stopped after 1 drift", Reports: []pkg.Report{clean}},
+		{StartedAt: day(3), Reports: []pkg.Report{clean}},
+	}
+
+	history := History(runs)
+
+	resolved2, resolved3 := day(2), day(3)
+	assert.Equal(t, []InstanceHistory{
+		{InstanceID: "i-1", Periods: []Period{
+			{Since: day(1), Resolved: &resolved3, Comment: pkg.CommentDriftDetected, Drifts: []string{pkg.AttrInstanceType}},
+		}},
+		{InstanceID: "i-2", Periods: []Period{
+			{Since: day(1), Resolved: &resolved2, Comment: pkg.CommentDriftDetected, Drifts: []string{pkg.AttrTags}},
+		}},
+	}, history, "instances left out of a partial run should stay drifting")
+}
@@ -0,0 +1,118 @@
+// Package snapshot persists the reports of each run to a directory of JSON files,
+// so drift can be followed across runs.
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tpriime/ec2diff/pkg"
+)
+
+// Layout of run IDs, which sort in the order runs started.
+const idLayout = "20060102T150405.000000000Z"
+
+// Run is the snapshot of one run.
+type Run struct {
+	ID         string       `json:"id"`
+	StartedAt  time.Time    `json:"started_at"`
+	Files      []string     `json:"files"`
+	Attributes []string     `json:"attributes"`
+	StateHash  string       `json:"state_hash"`        // Hash of the instances read from the states
+	Partial    string       `json:"partial,omitempty"` // Why some instances were not checked, if any were not
+	Summary    pkg.Summary  `json:"summary"`
+	Reports    []pkg.Report `json:"reports"`
+
+	FailedPages []pkg.FailedPage `json:"failed_pages,omitempty"` // Pages of live instances that could not be fetched
+}
+
+// NewRun creates the snapshot of a run started at startedAt.
+func NewRun(startedAt time.Time, files, attributes []string, state pkg.InstanceMap, reports []pkg.Report) Run {
+	startedAt = startedAt.UTC()
+	return Run{
+		ID:         startedAt.Format(idLayout),
+		StartedAt:  startedAt,
+		Files:      files,
+		Attributes: attributes,
+		StateHash:  HashState(state),
+		Summary:    pkg.Summarize(reports),
+		Reports:    reports,
+	}
+}
+
+// HashState returns a SHA-256 hash of the instances, which changes whenever the state does.
+func HashState(state pkg.InstanceMap) string {
+	data, _ := json.Marshal(state) // map keys are sorted, so the encoding is stable
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Store keeps one JSON file per run in a directory.
+type Store struct {
+	dir string
+}
+
+// NewStore creates a store in dir. The directory is created on the first save.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Save writes the run to the store and returns the path of its file.
+func (s *Store) Save(run Run) (string, error) {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(s.dir, run.ID+".json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return path, nil
+}
+
+// List returns the stored runs, oldest first.
+func (s *Store) List() ([]Run, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []Run
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var run Run
+		if err := json.Unmarshal(data, &run); err != nil {
+			return nil, fmt.Errorf("failed to read snapshot %s: %w", entry.Name(), err)
+		}
+		runs = append(runs, run)
+	}
+
+	slices.SortFunc(runs, func(a, b Run) int {
+		if c := a.StartedAt.Compare(b.StartedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return runs, nil
+}
@@ -0,0 +1,51 @@
+package snapshot
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tpriime/ec2diff/pkg"
+)
+
+func TestStore_SaveAndList(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "snapshots"))
+	state := pkg.InstanceMap{"i-1": pkg.Instance{ID: "i-1", Type: "t3.micro"}}
+	reports := []pkg.Report{{InstanceID: "i-1", Comment: pkg.CommentDriftDetected, Drifts: []pkg.AttributeDrift{
+		{Name: pkg.AttrInstanceType, Expected: "t3.large", Found: "t3.micro"},
+	}}}
+
+	later := NewRun(time.Date(2026, 6, 2, 0, 0, 0, 0, time.UTC), []string{"a.tfstate"}, nil, state, nil)
+	earlier := NewRun(time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC), []string{"a.tfstate"}, []string{pkg.AttrInstanceType}, state, reports)
+	for _, run := range []Run{later, earlier} {
+		path, err := store.Save(run)
+		assert.NoError(t, err)
+		assert.FileExists(t, path)
+	}
+
+	runs, err := store.List()
+
+	assert.NoError(t, err)
+	assert.Len(t, runs, 2)
+	assert.Equal(t, earlier.ID, runs[0].ID, "runs should be listed oldest first")
+	assert.Equal(t, pkg.Summary{Total: 1, Drifted: 1}, runs[0].Summary)
+	assert.Equal(t, "t3.large", runs[0].Reports[0].Drifts[0].Expected)
+	assert.Equal(t, runs[0].StateHash, runs[1].StateHash)
+}
+
+func TestStore_ListMissingDir(t *testing.T) {
+	runs, err := NewStore(filepath.Join(t.TempDir(), "missing")).List()
+
+	assert.NoError(t, err)
+	assert.Empty(t, runs)
+}
+
+func TestHashState(t *testing.T) {
+	a := pkg.InstanceMap{"i-1": pkg.Instance{ID: "i-1", Tags: map[string]string{"a": "1", "b": "2"}}}
+	b := pkg.InstanceMap{"i-1": pkg.Instance{ID: "i-1", Tags: map[string]string{"b": "2", "a": "1"}}}
+	c := pkg.InstanceMap{"i-1": pkg.Instance{ID: "i-1", Tags: map[string]string{"a": "1"}}}
+
+	assert.Equal(t, HashState(a), HashState(b))
+	assert.NotEqual(t, HashState(a), HashState(c))
+}
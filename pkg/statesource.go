@@ -0,0 +1,14 @@
+package pkg
+
+import "context"
+
+// StateSource defines how Terraform state would be read from a remote backend.
+// Sources only read the state and never take the backend's locks.
+type StateSource interface {
+
+	// Read fetches the raw state identified by uri (e.g. s3://bucket/key).
+	Read(ctx context.Context, uri string) ([]byte, error)
+
+	// SupportedSchemes lists the URI schemes this source can handle.
+	SupportedSchemes() []string
+}
@@ -0,0 +1,133 @@
+// Package suppress applies the rules of a .ec2diffignore file, marking known and
+// accepted drift as suppressed so that only new drift fails a run.
+package suppress
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/tpriime/ec2diff/pkg"
+	"github.com/tpriime/ec2diff/pkg/logger"
+	"github.com/tpriime/ec2diff/pkg/tagglob"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile is the suppression file looked up in the working directory.
+const DefaultFile = ".ec2diffignore"
+
+// Layout of the expiry dates.
+const dateLayout = time.DateOnly
+
+// yamlFile is the YAML layout of a suppression file.
+type yamlFile struct {
+	Rules []pkg.SuppressionRule `yaml:"rules"`
+}
+
+// hclFile is the HCL layout of a suppression file, with one block per rule.
+type hclFile struct {
+	Rules []pkg.SuppressionRule `hcl:"rule,block"`
+}
+
+// Load reads the suppression rules at path, in YAML or HCL.
+//
+// The format is taken from a .yaml, .yml or .hcl extension; any other file is
+// tried as HCL, then as YAML. Rules that expired before now are dropped.
+func Load(ctx context.Context, path string, now time.Time) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []pkg.SuppressionRule
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		rules, err = decodeYAML(data)
+	case ".hcl":
+		rules, err = decodeHCL(path, data)
+	default:
+		var hclErr error
+		if rules, hclErr = decodeHCL(path, data); hclErr != nil {
+			if rules, err = decodeYAML(data); err != nil {
+				err = errors.Join(hclErr, err)
+			}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	active := make([]pkg.SuppressionRule, 0, len(rules))
+	for i, rule := range rules {
+		if err := validate(rule); err != nil {
+			return nil, fmt.Errorf("invalid rule %d in %s: %w", i+1, path, err)
+		}
+		if expired(rule, now) {
+			logger.Warn(ctx, "Ignoring expired suppression rule", "rule", i+1, "expires", rule.Expires, "reason", rule.Reason)
+			continue
+		}
+		active = append(active, rule)
+	}
+
+	logger.Info(ctx, fmt.Sprintf("Loaded %d suppression rules", len(active)), "file", path)
+	return &Rules{rules: active}, nil
+}
+
+// decodeYAML decodes a YAML suppression file. Unknown keys are rejected, as a
+// misspelled key would otherwise leave a rule broader than intended.
+func decodeYAML(data []byte) ([]pkg.SuppressionRule, error) {
+	var f yamlFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return f.Rules, nil
+}
+
+func decodeHCL(path string, data []byte) ([]pkg.SuppressionRule, error) {
+	file, diags := hclparse.NewParser().ParseHCL(data, path)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	var f hclFile
+	if diags := gohcl.DecodeBody(file.Body, nil, &f); diags.HasErrors() {
+		return nil, diags
+	}
+	return f.Rules, nil
+}
+
+// validate rejects rules that would match every drift or have a malformed expiry.
+func validate(rule pkg.SuppressionRule) error {
+	if rule.InstanceID == "" && rule.Address == "" && len(rule.Tags) == 0 &&
+		rule.Attribute == "" && len(rule.TagKeys) == 0 {
+		return errors.New("at least one of instance_id, address, tags, attribute or tag_keys is required")
+	}
+	for _, glob := range rule.TagKeys {
+		if err := tagglob.Validate(glob); err != nil {
+			return fmt.Errorf("invalid tag key glob '%s': %w", glob, err)
+		}
+	}
+	if rule.Expires != "" {
+		if _, err := time.Parse(dateLayout, rule.Expires); err != nil {
+			return fmt.Errorf("invalid expiry '%s'. Expected YYYY-MM-DD", rule.Expires)
+		}
+	}
+	return nil
+}
+
+// expired reports whether the rule's expiry date has passed. Rules apply through the whole expiry day.
+func expired(rule pkg.SuppressionRule, now time.Time) bool {
+	if rule.Expires == "" {
+		return false
+	}
+	expires, _ := time.Parse(dateLayout, rule.Expires)
+	return !now.UTC().Before(expires.AddDate(0, 0, 1))
+}
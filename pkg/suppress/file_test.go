@@ -0,0 +1,96 @@
+package suppress
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tpriime/ec2diff/pkg"
+)
+
+var now = time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const yamlRules = `
+rules:
+  - attribute: instance_state
+    tags:
+      aws:autoscaling:groupName: web
+    reason: Managed by the autoscaling group
+  - tag_keys: ["backup:*"]
+    expires: 2026-06-15
+  - instance_id: i-123
+    expires: 2026-06-14
+`
+
+const hclRules = `
+rule {
+  attribute = "instance_state"
+  tags      = { "aws:autoscaling:groupName" = "web" }
+  reason    = "Managed by the autoscaling group"
+}
+
+rule {
+  tag_keys = ["backup:*"]
+  expires  = "2026-06-15"
+}
+
+rule {
+  instance_id = "i-123"
+  expires     = "2026-06-14"
+}
+`
+
+func TestLoad(t *testing.T) {
+	want := []pkg.SuppressionRule{
+		{
+			Attribute: pkg.AttrInstanceState,
+			Tags:      map[string]string{"aws:autoscaling:groupName": "web"},
+			Reason:    "Managed by the autoscaling group",
+		},
+		{TagKeys: []string{"backup:*"}, Expires: "2026-06-15"},
+	}
+
+	for name, path := range map[string]string{
+		"yaml by extension": writeFile(t, "ignore.yaml", yamlRules),
+		"hcl by extension":  writeFile(t, "ignore.hcl", hclRules),
+		"yaml by content":   writeFile(t, DefaultFile, yamlRules),
+		"hcl by content":    writeFile(t, DefaultFile, hclRules),
+	} {
+		t.Run(name, func(t *testing.T) {
+			rules, err := Load(t.Context(), path, now)
+
+			assert.NoError(t, err)
+			assert.Equal(t, want, rules.rules, "expired rules should be dropped")
+		})
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	for name, tc := range map[string]struct {
+		content string
+		err     string
+	}{
+		"matches everything": {"rules:\n  - reason: everything\n", "at least one of"},
+		"bad expiry":         {"rules:\n  - instance_id: i-1\n    expires: soon\n", "invalid expiry 'soon'"},
+		"bad glob":           {"rules:\n  - tag_keys: ['[']\n", "invalid tag key glob"},
+		"unknown format":     {"{{{", "failed to parse"},
+		"unknown key":        {"rules:\n  - instance-id: i-1\n    attribute: tags\n", "field instance-id not found"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Load(t.Context(), writeFile(t, DefaultFile, tc.content), now)
+
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package suppress
+
+import (
+	"strings"
+
+	"github.com/tpriime/ec2diff/pkg"
+	"github.com/tpriime/ec2diff/pkg/tagglob"
+)
+
+// Rules holds the active suppression rules.
+type Rules struct {
+	rules []pkg.SuppressionRule
+}
+
+// NewRules creates a rule set from rules that are already validated.
+func NewRules(rules []pkg.SuppressionRule) *Rules {
+	return &Rules{rules: rules}
+}
+
+// Apply marks the drifts of report matched by a rule as suppressed, recording the first matching rule.
+// inst is the instance the report is about, whose tags are matched against tag selectors.
+// The report itself is suppressed once all of its drifts are.
+func (r *Rules) Apply(report *pkg.Report, inst pkg.Instance) {
+	if r == nil || len(report.Drifts) == 0 {
+		return
+	}
+
+	suppressed := 0
+	for i := range report.Drifts {
+		d := &report.Drifts[i]
+		for j := range r.rules {
+			if matches(&r.rules[j], report, inst, *d) {
+				d.Suppressed = true
+				d.Rule = &r.rules[j]
+				suppressed++
+				break
+			}
+		}
+	}
+	report.Suppressed = suppressed == len(report.Drifts)
+}
+
+// matches reports whether every selector set on rule matches the drift.
+func matches(rule *pkg.SuppressionRule, report *pkg.Report, inst pkg.Instance, d pkg.AttributeDrift) bool {
+	if rule.InstanceID != "" && rule.InstanceID != report.InstanceID {
+		return false
+	}
+	if rule.Address != "" && rule.Address != report.Address {
+		return false
+	}
+	for key, value := range rule.Tags {
+		if v, ok := inst.Tags[key]; !ok || v != value {
+			return false
+		}
+	}
+	if rule.Attribute != "" && rule.Attribute != d.Name && !strings.HasPrefix(d.Name, rule.Attribute+".") {
+		return false
+	}
+	if len(rule.TagKeys) > 0 {
+		var keys []string
+		if key, ok := strings.CutPrefix(d.Name, pkg.AttrTags+"."); ok {
+			keys = []string{key}
+		} else if d.Name == pkg.AttrTags {
+			keys = changedKeys(d.Expected, d.Found)
+		}
+		return len(keys) > 0 && allMatch(keys, rule.TagKeys)
+	}
+	return true
+}
+
+// changedKeys returns the tag keys whose values differ between two tag maps,
+// for tags reported as a whole, as they are for missing instances.
+// A value that is not a tag map, such as that of a missing instance, counts as no tags.
+func changedKeys(a, b any) []string {
+	ta, _ := a.(map[string]string)
+	tb, _ := b.(map[string]string)
+
+	var keys []string
+	for key, v := range ta {
+		if other, ok := tb[key]; !ok || other != v {
+			keys = append(keys, key)
+		}
+	}
+	for key := range tb {
+		if _, ok := ta[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// allMatch reports whether every key matches at least one glob.
+func allMatch(keys, globs []string) bool {
+	for _, key := range keys {
+		if !tagglob.MatchAny(key, globs) {
+			return false
+		}
+	}
+	return true
+}
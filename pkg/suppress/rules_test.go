@@ -0,0 +1,89 @@
+package suppress
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tpriime/ec2diff/pkg"
+)
+
+func TestApply(t *testing.T) {
+	asg := pkg.Instance{ID: "i-1", Tags: map[string]string{"aws:autoscaling:groupName": "web"}}
+	stateDrift := pkg.AttributeDrift{Name: pkg.AttrInstanceState, Expected: "stopped", Found: "running"}
+	backupTag := pkg.AttributeDrift{
+		Name:     pkg.AttrTags,
+		Expected: map[string]string{"Name": "web", "backup:last": "today"},
+		Found:    map[string]string{"Name": "web"},
+	}
+	ownerTag := pkg.AttributeDrift{
+		Name:     pkg.AttrTags,
+		Expected: map[string]string{"Owner": "bob", "backup:last": "today"},
+		Found:    map[string]string{"Owner": "alice"},
+	}
+	backupKey := pkg.AttributeDrift{Name: "tags.backup:last", Expected: "today", Found: "-"}
+	ownerKey := pkg.AttributeDrift{Name: "tags.Owner", Expected: "bob", Found: "alice"}
+	clusterKey := pkg.AttributeDrift{Name: "tags.kubernetes.io/cluster/x", Expected: "-", Found: "owned"}
+	clusterTag := pkg.AttributeDrift{
+		Name:     pkg.AttrTags,
+		Expected: map[string]string{"Name": "web"},
+		Found:    map[string]string{"Name": "web", "kubernetes.io/cluster/x": "owned"},
+	}
+
+	for name, tc := range map[string]struct {
+		rule       pkg.SuppressionRule
+		inst       pkg.Instance
+		drift      pkg.AttributeDrift
+		suppressed bool
+	}{
+		"by instance ID":          {pkg.SuppressionRule{InstanceID: "i-1"}, asg, stateDrift, true},
+		"other instance ID":       {pkg.SuppressionRule{InstanceID: "i-2"}, asg, stateDrift, false},
+		"by address":              {pkg.SuppressionRule{Address: "aws_instance.web"}, asg, stateDrift, true},
+		"by attribute":            {pkg.SuppressionRule{Attribute: pkg.AttrInstanceState}, asg, stateDrift, true},
+		"other attribute":         {pkg.SuppressionRule{Attribute: pkg.AttrInstanceType}, asg, stateDrift, false},
+		"by tag selector":         {pkg.SuppressionRule{Tags: map[string]string{"aws:autoscaling:groupName": "web"}}, asg, stateDrift, true},
+		"tag selector mismatch":   {pkg.SuppressionRule{Tags: map[string]string{"aws:autoscaling:groupName": "api"}}, asg, stateDrift, false},
+		"tag keys all match":      {pkg.SuppressionRule{TagKeys: []string{"backup:*"}}, asg, backupTag, true},
+		"tag keys partly match":   {pkg.SuppressionRule{TagKeys: []string{"backup:*"}}, asg, ownerTag, false},
+		"tag keys other attr":     {pkg.SuppressionRule{TagKeys: []string{"*"}}, asg, stateDrift, false},
+		"tag keys per key":        {pkg.SuppressionRule{TagKeys: []string{"backup:*"}}, asg, backupKey, true},
+		"tag keys other key":      {pkg.SuppressionRule{TagKeys: []string{"backup:*"}}, asg, ownerKey, false},
+		"tag keys nested key":     {pkg.SuppressionRule{TagKeys: []string{"kubernetes.io/*"}}, asg, clusterKey, true},
+		"tag keys nested tag":     {pkg.SuppressionRule{TagKeys: []string{"kubernetes.io/*"}}, asg, clusterTag, true},
+		"attribute covers keys":   {pkg.SuppressionRule{Attribute: pkg.AttrTags}, asg, ownerKey, true},
+		"every selector must hit": {pkg.SuppressionRule{InstanceID: "i-1", Attribute: pkg.AttrInstanceType}, asg, stateDrift, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			report := pkg.Report{InstanceID: "i-1", Address: "aws_instance.web", Drifts: []pkg.AttributeDrift{tc.drift}}
+
+			NewRules([]pkg.SuppressionRule{tc.rule}).Apply(&report, tc.inst)
+
+			assert.Equal(t, tc.suppressed, report.Drifts[0].Suppressed)
+			assert.Equal(t, tc.suppressed, report.Suppressed)
+			if tc.suppressed {
+				assert.Equal(t, tc.rule, *report.Drifts[0].Rule)
+			}
+		})
+	}
+}
+
+func TestApply_PartiallySuppressed(t *testing.T) {
+	report := pkg.Report{InstanceID: "i-1", Drifts: []pkg.AttributeDrift{
+		{Name: pkg.AttrInstanceState, Expected: "stopped", Found: "running"},
+		{Name: pkg.AttrInstanceType, Expected: "t3.large", Found: "t3.micro"},
+	}}
+
+	NewRules([]pkg.SuppressionRule{{Attribute: pkg.AttrInstanceState}}).Apply(&report, pkg.Instance{})
+
+	assert.True(t, report.Drifts[0].Suppressed)
+	assert.False(t, report.Drifts[1].Suppressed)
+	assert.False(t, report.Suppressed, "a report with new drift should not be suppressed")
+}
+
+func TestApply_NilRules(t *testing.T) {
+	report := pkg.Report{InstanceID: "i-1", Drifts: []pkg.AttributeDrift{{Name: pkg.AttrInstanceState}}}
+
+	var rules *Rules
+	rules.Apply(&report, pkg.Instance{})
+
+	assert.False(t, report.Suppressed)
+}
@@ -5,63 +5,148 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/tpriime/ec2diff/pkg"
 )
 
-// Report captures drift for one instance
+// tablePrinter prints each report as a table of drifted attributes, as it is generated.
 type tablePrinter struct {
-	out io.Writer
+	out     io.Writer
+	printed int // Number of reports printed so far
 }
 
 func NewTablePrinter(output io.Writer) pkg.ReportPrinter {
 	return &tablePrinter{out: output}
 }
 
-func (t tablePrinter) Print(reports []pkg.Report) {
-	w := tabwriter.NewWriter(t.out, 0, 0, 2, ' ', 0)
+// Begin prints the report header.
+func (t *tablePrinter) Begin() {
+	t.printed = 0
 
 	// Print report header with decoration
-	fmt.Fprintln(w, "==============================")
-	fmt.Fprintln(w, "            REPORT")
-	fmt.Fprintf(w, "==============================\n\n")
+	fmt.Fprintln(t.out, "==============================")
+	fmt.Fprintln(t.out, "            REPORT")
+	fmt.Fprintf(t.out, "==============================\n\n")
+}
 
-	for i, r := range reports {
-		// Print instance ID and optional comment
-		fmt.Fprintf(w, "Instance [%d]   \t: %s\n", i+1, r.InstanceID)
-		fmt.Fprintf(w, "Comment         \t: %s\n", r.Comment)
+// PrintReport prints the report of one instance, aligned on its own.
+func (t *tablePrinter) PrintReport(r pkg.Report) {
+	w := tabwriter.NewWriter(t.out, 0, 0, 2, ' ', 0)
+	defer w.Flush()
 
-		// Print header and drift entries
-		if len(r.Drifts) != 0 {
-			fmt.Fprintln(w)
-			fmt.Fprintln(w, "Attribute       \tLive                               \tState")
-			fmt.Fprintln(w, "-------------   \t----------------------------------   \t------------------------------")
-		}
+	// Separate instance reports with spacing and em dash line
+	if t.printed > 0 {
+		fmt.Fprintf(w, "\n——\n\n")
+	}
+	t.printed++
 
-		for _, d := range r.Drifts {
-			expected := d.Expected
-			found := d.Found
+	// Print instance ID and optional comment
+	fmt.Fprintf(w, "Instance [%d]   \t: %s\n", t.printed, r.InstanceID)
+	if r.Address != "" {
+		fmt.Fprintf(w, "Address         \t: %s\n", r.Address)
+	}
+	if len(r.Sources) > 0 {
+		fmt.Fprintf(w, "Source          \t: %s\n", strings.Join(r.Sources, ", "))
+	}
+	if r.Account != "" {
+		fmt.Fprintf(w, "Account         \t: %s\n", r.Account)
+	}
+	if r.Region != "" {
+		fmt.Fprintf(w, "Region          \t: %s\n", r.Region)
+	}
+	comment := r.Comment
+	if r.Conflict {
+		comment += " (claimed by multiple states)"
+	}
+	if r.Suppressed {
+		comment += " (suppressed)"
+	}
+	fmt.Fprintf(w, "Comment         \t: %s\n", comment)
+	if r.Change != "" {
+		fmt.Fprintf(w, "Change          \t: %s\n", r.Change)
+	}
 
-			if isMapOrSlice(expected) {
-				expected = toJSONString(expected)
-			}
-			if isMapOrSlice(found) {
-				found = toJSONString(found)
-			}
+	// Print header and drift entries
+	if len(r.Drifts) != 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Attribute       \tLive                               \tState")
+		fmt.Fprintln(w, "-------------   \t----------------------------------   \t------------------------------")
+	}
 
-			fmt.Fprintf(w, "%-15s\t%-35v\t%-30v\n", d.Name, expected, found)
+	for _, d := range r.Drifts {
+		expected := d.Expected
+		found := d.Found
+
+		// Print nil as JSON too, as reports loaded from JSON hold null for nil slices and maps
+		if expected == nil || isMapOrSlice(expected) {
+			expected = toJSONString(expected)
+		}
+		if found == nil || isMapOrSlice(found) {
+			found = toJSONString(found)
 		}
 
-		// Separate instance reports with spacing and em dash line
-		if i < len(reports)-1 {
-			fmt.Fprintf(w, "\n——\n\n")
+		name := d.Name
+		if d.Suppressed {
+			name += " (suppressed)"
+		}
+		if d.Change != "" && d.Change != r.Change {
+			name += " (" + d.Change + ")"
+		}
+
+		fmt.Fprintf(w, "%-15s\t%-35v\t%-30v\n", name, expected, found)
+	}
+}
+
+// End prints the summary, with the reason the run is partial, followed by the pages
+// of live instances that could not be fetched, if any.
+func (t *tablePrinter) End(summary pkg.Summary, partial string, failed []pkg.FailedPage) {
+	w := tabwriter.NewWriter(t.out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "==============================")
+	fmt.Fprintln(w, "           SUMMARY")
+	fmt.Fprintf(w, "==============================\n\n")
+	fmt.Fprintf(w, "Total\t: %d\n", summary.Total)
+	if c := summary.Changes; c != nil {
+		fmt.Fprintf(w, "New\t: %d\n", c.New)
+		fmt.Fprintf(w, "Resolved\t: %d\n", c.Resolved)
+		fmt.Fprintf(w, "Changed\t: %d\n", c.Changed)
+		fmt.Fprintf(w, "Unchanged\t: %d\n", c.Unchanged)
+	} else {
+		fmt.Fprintf(w, "Drifted\t: %d\n", summary.Drifted)
+		fmt.Fprintf(w, "No drift\t: %d\n", summary.NoDrift)
+		fmt.Fprintf(w, "Missing state\t: %d\n", summary.MissingState)
+		fmt.Fprintf(w, "Missing live\t: %d\n", summary.MissingLive)
+		fmt.Fprintf(w, "Conflicts\t: %d\n", summary.Conflicts)
+		fmt.Fprintf(w, "Suppressed\t: %d\n", summary.Suppressed)
+	}
+	if partial != "" {
+		fmt.Fprintf(w, "Partial\t: %s\n", partial)
+	}
+
+	if len(failed) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "==============================")
+		fmt.Fprintln(w, "  PARTIAL: PAGES NOT FETCHED")
+		fmt.Fprintf(w, "==============================\n\n")
+		fmt.Fprintln(w, "Page\tRegion\tError")
+		for _, p := range failed {
+			region := p.Region
+			if region == "" {
+				region = "-"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", p.Page, region, p.Error)
 		}
 	}
 	w.Flush()
 }
 
 func isMapOrSlice(v interface{}) bool {
+	if v == nil {
+		return false
+	}
 	kind := reflect.TypeOf(v).Kind()
 	return kind == reflect.Map || kind == reflect.Slice
 }
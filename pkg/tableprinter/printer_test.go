@@ -2,6 +2,7 @@ package tableprinter
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,14 +11,15 @@ import (
 
 func TestReport_Print_NoDrifts(t *testing.T) {
 	var buf bytes.Buffer
-	printer := tablePrinter{out: &buf}
+	printer := &tablePrinter{out: &buf}
 	report := pkg.Report{
 		InstanceID: "i-123456",
 		Drifts:     nil,
 		Comment:    "No drifts detected for i-123456",
 	}
 
-	printer.Print([]pkg.Report{report})
+	reports := []pkg.Report{report}
+	pkg.PrintAll(printer, reports, pkg.Summarize(reports))
 
 	output := buf.String()
 	assert.Contains(t, output, "No drifts detected for i-123456", "expected output to mention no drifts")
@@ -25,7 +27,7 @@ func TestReport_Print_NoDrifts(t *testing.T) {
 
 func TestReport_Print_WithDrifts(t *testing.T) {
 	var buf bytes.Buffer
-	printer := tablePrinter{out: &buf}
+	printer := &tablePrinter{out: &buf}
 	report := pkg.Report{
 		InstanceID: "i-7890",
 		Drifts: []pkg.AttributeDrift{
@@ -42,7 +44,8 @@ func TestReport_Print_WithDrifts(t *testing.T) {
 		},
 	}
 
-	printer.Print([]pkg.Report{report})
+	reports := []pkg.Report{report}
+	pkg.PrintAll(printer, reports, pkg.Summarize(reports))
 
 	output := buf.String()
 	assert.Contains(t, output, "i-7890", "expected instance id")
@@ -53,3 +56,118 @@ func TestReport_Print_WithDrifts(t *testing.T) {
 	assert.Contains(t, output, `{"env":"prod"}`, "expected tags drift row with JSON (expected)")
 	assert.Contains(t, output, `{"env":"dev"}`, "expected tags drift row with JSON (actual)")
 }
+
+func TestReport_Print_AccountAndRegion(t *testing.T) {
+	var buf bytes.Buffer
+	printer := &tablePrinter{out: &buf}
+
+	reports := []pkg.Report{
+		{InstanceID: "i-1", Account: "123456789012", Region: "eu-west-1", Comment: pkg.CommentNoDriftDetected},
+		{InstanceID: "i-2", Comment: pkg.CommentMissingLive},
+	}
+	pkg.PrintAll(printer, reports, pkg.Summarize(reports))
+
+	output := buf.String()
+	assert.Contains(t, output, "123456789012")
+	assert.Contains(t, output, "eu-west-1")
+	assert.Equal(t, 1, strings.Count(output, "Region"), "region should only be printed when known")
+}
+
+func TestReport_Print_Address(t *testing.T) {
+	var buf bytes.Buffer
+	printer := &tablePrinter{out: &buf}
+
+	reports := []pkg.Report{
+		{InstanceID: "i-1", Address: `module.web.aws_instance.app["blue"]`, Comment: pkg.CommentNoDriftDetected},
+		{InstanceID: "i-2", Comment: pkg.CommentMissingState},
+	}
+	pkg.PrintAll(printer, reports, pkg.Summarize(reports))
+
+	output := buf.String()
+	assert.Contains(t, output, `module.web.aws_instance.app["blue"]`)
+	assert.Equal(t, 1, strings.Count(output, "Address"), "address should only be printed when known")
+}
+
+func TestReport_Print_Suppressed(t *testing.T) {
+	var buf bytes.Buffer
+	printer := &tablePrinter{out: &buf}
+
+	reports := []pkg.Report{{
+		InstanceID: "i-1",
+		Comment:    pkg.CommentDriftDetected,
+		Suppressed: true,
+		Drifts:     []pkg.AttributeDrift{{Name: pkg.AttrInstanceState, Expected: "stopped", Found: "running", Suppressed: true}},
+	}}
+	pkg.PrintAll(printer, reports, pkg.Summarize(reports))
+
+	output := buf.String()
+	assert.Contains(t, output, "Drifts detected (suppressed)")
+	assert.Contains(t, output, "instance_state (suppressed)")
+}
+
+func TestReport_Print_Change(t *testing.T) {
+	var buf bytes.Buffer
+	printer := &tablePrinter{out: &buf}
+
+	reports := []pkg.Report{{
+		InstanceID: "i-1",
+		Comment:    pkg.CommentDriftDetected,
+		Change:     pkg.ChangeChanged,
+		Drifts: []pkg.AttributeDrift{
+			{Name: "tags.Owner", Expected: "bob", Found: "alice", Change: pkg.ChangeNew},
+			{Name: pkg.AttrInstanceType, Expected: "t3.large", Found: "t3.micro", Change: pkg.ChangeChanged},
+		},
+	}}
+	pkg.PrintAll(printer, reports, pkg.Summarize(reports))
+
+	output := buf.String()
+	assert.Regexp(t, `Change +: changed`, output)
+	assert.Contains(t, output, "tags.Owner (new)")
+	assert.NotContains(t, output, "instance_type (changed)", "drifts changed like their report should not be labelled")
+}
+
+func TestReport_PrintPartial(t *testing.T) {
+	var buf bytes.Buffer
+	printer := &tablePrinter{out: &buf}
+
+	printer.Begin()
+	printer.PrintReport(pkg.Report{InstanceID: "i-1", Comment: pkg.CommentNoDriftDetected})
+	printer.End(pkg.Summary{Total: 1, NoDrift: 1}, "", []pkg.FailedPage{
+		{Page: 3, Region: "eu-west-1", Error: "RequestLimitExceeded"},
+		{Page: 1, Error: "timeout"},
+	})
+
+	output := buf.String()
+	assert.Contains(t, output, "i-1")
+	assert.Contains(t, output, "PARTIAL: PAGES NOT FETCHED")
+	assert.Regexp(t, `3 +eu-west-1 +RequestLimitExceeded`, output)
+	assert.Regexp(t, `1 +- +timeout`, output)
+}
+
+func TestReport_Print_Streams(t *testing.T) {
+	var buf bytes.Buffer
+	printer := &tablePrinter{out: &buf}
+
+	printer.Begin()
+	printer.PrintReport(pkg.Report{InstanceID: "i-1", Comment: pkg.CommentNoDriftDetected})
+	assert.Contains(t, buf.String(), "Instance [1]", "reports should be printed before the end")
+	assert.NotContains(t, buf.String(), "SUMMARY")
+
+	printer.PrintReport(pkg.Report{InstanceID: "i-2", Comment: pkg.CommentMissingLive})
+	printer.End(pkg.Summary{Total: 2, NoDrift: 1, MissingLive: 1}, "", nil)
+
+	output := buf.String()
+	assert.Regexp(t, `Instance \[2\] +: i-2`, output)
+	assert.Equal(t, 1, strings.Count(output, "——"), "reports should be separated")
+	assert.Regexp(t, `SUMMARY(.|\n)*Total +: 2\n(.|\n)*Missing live +: 1`, output)
+}
+
+func TestReport_Print_NotPartial(t *testing.T) {
+	var buf bytes.Buffer
+	printer := &tablePrinter{out: &buf}
+
+	reports := []pkg.Report{{InstanceID: "i-1", Comment: pkg.CommentNoDriftDetected}}
+	pkg.PrintAll(printer, reports, pkg.Summarize(reports))
+
+	assert.NotContains(t, buf.String(), "PARTIAL")
+}
@@ -0,0 +1,35 @@
+// Package tagglob matches tag keys against shell-style globs.
+package tagglob
+
+import (
+	"path"
+	"strings"
+)
+
+// sep stands in for '/' while matching, as path.Match never lets '*' match a separator.
+// Tag keys cannot hold it, as AWS only allows letters, digits, spaces and + - = . _ : / @.
+const sep = "\x00"
+
+// Match reports whether key matches glob, with the syntax of path.Match except
+// that '*' and '?' also match '/'. So kubernetes.io/* matches kubernetes.io/cluster/prod.
+// A malformed glob matches nothing.
+func Match(glob, key string) bool {
+	ok, _ := path.Match(strings.ReplaceAll(glob, "/", sep), strings.ReplaceAll(key, "/", sep))
+	return ok
+}
+
+// MatchAny reports whether key matches at least one glob.
+func MatchAny(key string, globs []string) bool {
+	for _, glob := range globs {
+		if Match(glob, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns an error if glob is malformed.
+func Validate(glob string) error {
+	_, err := path.Match(glob, "")
+	return err
+}
@@ -0,0 +1,42 @@
+package tagglob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	tests := map[string]struct {
+		glob, key string
+		want      bool
+	}{
+		"exact":                       {"Name", "Name", true},
+		"prefix":                      {"aws:*", "aws:backup:source-resource", true},
+		"one segment":                 {"karpenter.sh/*", "karpenter.sh/nodepool", true},
+		"nested segments":             {"kubernetes.io/*", "kubernetes.io/cluster/prod", true},
+		"question mark matches slash": {"a?b", "a/b", true},
+		"class":                       {"env-[ps]*", "env-prod", true},
+		"other prefix":                {"kubernetes.io/*", "karpenter.sh/nodepool", false},
+		"no partial match":            {"Name", "Names", false},
+		"malformed":                   {"[", "[", false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Match(tt.glob, tt.key))
+		})
+	}
+}
+
+func TestMatchAny(t *testing.T) {
+	globs := []string{"aws:*", "kubernetes.io/*"}
+
+	assert.True(t, MatchAny("kubernetes.io/cluster/x", globs))
+	assert.False(t, MatchAny("Name", globs))
+	assert.False(t, MatchAny("Name", nil))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate("kubernetes.io/*"))
+	assert.Error(t, Validate("["))
+}
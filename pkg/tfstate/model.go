@@ -1,45 +1,99 @@
 package tfstate
 
-import "github.com/tpriime/ec2diff/pkg"
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tpriime/ec2diff/pkg"
+)
 
 // state mirrors the Terraform state JSON structure minimally
 type state struct {
-	Resources []struct {
-		Type      string       `json:"type"`
-		Instances []tfInstance `json:"instances"`
-	} `json:"resources"`
+	Resources []stateResource `json:"resources"`
+}
+
+type stateResource struct {
+	Module    string       `json:"module"`
+	Mode      string       `json:"mode"`
+	Type      string       `json:"type"`
+	Name      string       `json:"name"`
+	Instances []tfInstance `json:"instances"`
 }
 
 type tfInstance struct {
-	Attributes struct {
-		ID                  string            `json:"id"`
-		Ami                 string            `json:"ami"`
-		AvailabilityZone    string            `json:"availability_zone"`
-		InstanceType        string            `json:"instance_type"`
-		InstanceState       string            `json:"instance_state"`
-		KeyName             string            `json:"key_name"`
-		Monitoring          bool              `json:"monitoring"`
-		PublicIP            string            `json:"public_ip"`
-		SubnetID            string            `json:"subnet_id"`
-		SecurityGroups      []string          `json:"security_groups"`
-		VpcID               string            `json:"vpc_id"`
-		VpcSecurityGroupIds []string          `json:"vpc_security_group_ids"`
-		Tags                map[string]string `json:"tags"`
-		Architecture        string            `json:"architecture"`
-		VirtualizationType  string            `json:"virtualization_type"`
-		IamInstanceProfile  string            `json:"iam_instance_profile"`
-	} `json:"attributes"`
+	IndexKey   any        `json:"index_key"` // set by count (a number) or for_each (a string)
+	Attributes attributes `json:"attributes"`
+}
+
+// address returns the resource address of inst, e.g. module.web.aws_instance.app["blue"].
+func (r stateResource) address(inst tfInstance) string {
+	addr := r.Type + "." + r.Name
+	if r.Mode == "data" {
+		addr = "data." + addr
+	}
+	if r.Module != "" {
+		addr = r.Module + "." + addr
+	}
+
+	switch key := inst.IndexKey.(type) {
+	case string:
+		quoted, _ := json.Marshal(key)
+		addr += "[" + string(quoted) + "]"
+	case float64:
+		addr += fmt.Sprintf("[%d]", int(key))
+	}
+	return addr
+}
+
+// attributes holds the aws_instance attributes shared by state, show and plan JSON.
+type attributes struct {
+	ID                  string            `json:"id"`
+	Ami                 string            `json:"ami"`
+	AvailabilityZone    string            `json:"availability_zone"`
+	InstanceType        string            `json:"instance_type"`
+	InstanceState       string            `json:"instance_state"`
+	KeyName             string            `json:"key_name"`
+	Monitoring          bool              `json:"monitoring"`
+	PublicIP            string            `json:"public_ip"`
+	PrivateIP           string            `json:"private_ip"`
+	SubnetID            string            `json:"subnet_id"`
+	SecurityGroups      []string          `json:"security_groups"`
+	VpcID               string            `json:"vpc_id"`
+	VpcSecurityGroupIds []string          `json:"vpc_security_group_ids"`
+	Tags                map[string]string `json:"tags"`
+	TagsAll             map[string]string `json:"tags_all"`
+	Architecture        string            `json:"architecture"`
+	VirtualizationType  string            `json:"virtualization_type"`
+	IamInstanceProfile  string            `json:"iam_instance_profile"`
 }
 
 func (i tfInstance) toInstance() pkg.Instance {
-	attr := i.Attributes
+	return i.Attributes.toInstance()
+}
+
+func (attr attributes) toInstance() pkg.Instance {
+	// tags_all also holds the provider's default_tags, which AWS reports as instance tags
+	tags := attr.Tags
+	if attr.TagsAll != nil {
+		tags = attr.TagsAll
+	}
 	return pkg.Instance{
-		ID:             attr.ID,
-		Type:           attr.InstanceType,
-		State:          attr.InstanceState,
-		KeyName:        attr.KeyName,
-		Tags:           attr.Tags,
-		SecurityGroups: attr.SecurityGroups,
-		PublicIP:       attr.PublicIP,
+		ID:                  attr.ID,
+		Type:                attr.InstanceType,
+		State:               attr.InstanceState,
+		KeyName:             attr.KeyName,
+		Tags:                tags,
+		SecurityGroups:      attr.SecurityGroups,
+		PublicIP:            attr.PublicIP,
+		PrivateIP:           attr.PrivateIP,
+		Ami:                 attr.Ami,
+		AvailabilityZone:    attr.AvailabilityZone,
+		SubnetID:            attr.SubnetID,
+		VpcID:               attr.VpcID,
+		VpcSecurityGroupIDs: attr.VpcSecurityGroupIds,
+		Monitoring:          attr.Monitoring,
+		Architecture:        attr.Architecture,
+		VirtualizationType:  attr.VirtualizationType,
+		IamInstanceProfile:  attr.IamInstanceProfile,
 	}
 }
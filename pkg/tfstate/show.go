@@ -0,0 +1,133 @@
+package tfstate
+
+import (
+	"encoding/json"
+
+	"github.com/tpriime/ec2diff/pkg"
+)
+
+// showOutput mirrors the output of `terraform show -json` for a state.
+type showOutput struct {
+	Values *values `json:"values"`
+}
+
+// planOutput mirrors the output of `terraform show -json` for a saved plan.
+type planOutput struct {
+	PlannedValues *values     `json:"planned_values"`
+	PriorState    *showOutput `json:"prior_state"`
+}
+
+type values struct {
+	RootModule module `json:"root_module"`
+}
+
+type module struct {
+	Resources    []resource `json:"resources"`
+	ChildModules []module   `json:"child_modules"`
+}
+
+type resource struct {
+	Address string          `json:"address"`
+	Mode    string          `json:"mode"`
+	Type    string          `json:"type"`
+	Values  json.RawMessage `json:"values"`
+}
+
+// decodeShow maps the aws_instance resources of `terraform show -json` output by ID.
+func decodeShow(data []byte) (pkg.InstanceMap, error) {
+	var out showOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out.Values.instances()
+}
+
+// decodePlan maps the aws_instance resources of plan JSON by ID.
+//
+// The planned values are used, as they are what the configuration declares.
+// Plans without them, such as those that failed, fall back to the prior state.
+func decodePlan(data []byte) (pkg.InstanceMap, error) {
+	var out planOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	if out.PlannedValues == nil && out.PriorState != nil {
+		return out.PriorState.Values.instances()
+	}
+	return out.PlannedValues.instances()
+}
+
+// instances walks the root module and its child modules for managed aws_instance resources.
+// Resources whose ID is not known yet, such as those about to be created, are skipped.
+func (v *values) instances() (pkg.InstanceMap, error) {
+	out := pkg.InstanceMap{}
+	if v == nil {
+		return out, nil
+	}
+
+	modules := []module{v.RootModule}
+	for len(modules) > 0 {
+		mod := modules[0]
+		modules = append(modules[1:], mod.ChildModules...)
+
+		for _, res := range mod.Resources {
+			if res.Type != "aws_instance" || res.Mode == "data" {
+				continue
+			}
+			inst, err := res.toInstance()
+			if err != nil {
+				return nil, err
+			}
+			if inst.ID != "" {
+				out[inst.ID] = inst
+			}
+		}
+	}
+	return out, nil
+}
+
+// toInstance decodes the resource values. Attributes left out of the values,
+// because they are unknown until apply or were redacted, are marked unknown.
+func (r resource) toInstance() (pkg.Instance, error) {
+	var attr attributes
+	if err := json.Unmarshal(r.Values, &attr); err != nil {
+		return pkg.Instance{}, err
+	}
+	var present map[string]json.RawMessage
+	if err := json.Unmarshal(r.Values, &present); err != nil {
+		return pkg.Instance{}, err
+	}
+
+	inst := attr.toInstance()
+	inst.Address = r.Address
+	for _, name := range supportedAttributes {
+		if _, ok := present[name]; ok {
+			continue
+		}
+		if _, ok := present["tags_all"]; ok && name == pkg.AttrTags {
+			continue
+		}
+		inst.Unknown = append(inst.Unknown, name)
+	}
+	return inst, nil
+}
+
+// supportedAttributes lists the attributes decoded from resource values, in sorted order.
+var supportedAttributes = []string{
+	pkg.AttrAmi,
+	pkg.AttrArchitecture,
+	pkg.AttrAvailabilityZone,
+	pkg.AttrIamInstanceProfile,
+	pkg.AttrInstanceState,
+	pkg.AttrInstanceType,
+	pkg.AttrKeyName,
+	pkg.AttrMonitoring,
+	pkg.AttrPrivateIP,
+	pkg.AttrPublicIP,
+	pkg.AttrSecurityGroups,
+	pkg.AttrSubnetID,
+	pkg.AttrTags,
+	pkg.AttrVirtualizationType,
+	pkg.AttrVpcID,
+	pkg.AttrVpcSecurityGroupIDs,
+}
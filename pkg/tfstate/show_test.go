@@ -0,0 +1,88 @@
+package tfstate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tpriime/ec2diff/pkg"
+)
+
+const showJSON = `{
+	"format_version": "1.0",
+	"values": {
+		"root_module": {
+			"resources": [
+				{"address": "aws_instance.web", "mode": "managed", "type": "aws_instance",
+				 "values": {"id": "i-web", "instance_type": "t3.micro", "tags_all": {"Name": "web"}}},
+				{"address": "data.aws_instance.lookup", "mode": "data", "type": "aws_instance",
+				 "values": {"id": "i-lookup"}}
+			],
+			"child_modules": [
+				{"address": "module.app", "resources": [
+					{"address": "module.app.aws_instance.app[0]", "mode": "managed", "type": "aws_instance",
+					 "values": {"id": "i-app", "instance_type": "t3.large"}}
+				]}
+			]
+		}
+	}
+}`
+
+const planJSON = `{
+	"format_version": "1.2",
+	"planned_values": {
+		"root_module": {
+			"resources": [
+				{"address": "aws_instance.web", "mode": "managed", "type": "aws_instance",
+				 "values": {"id": "i-web", "instance_type": "t3.small"}},
+				{"address": "aws_instance.new", "mode": "managed", "type": "aws_instance",
+				 "values": {"instance_type": "t3.small"}}
+			]
+		}
+	},
+	"prior_state": {
+		"values": {
+			"root_module": {
+				"resources": [
+					{"address": "aws_instance.web", "mode": "managed", "type": "aws_instance",
+					 "values": {"id": "i-web", "instance_type": "t3.micro"}}
+				]
+			}
+		}
+	}
+}`
+
+func TestParseData_Show(t *testing.T) {
+	instances, err := NewTfStateParser().ParseData([]byte(showJSON))
+
+	assert.NoError(t, err)
+	assert.Len(t, instances, 2, "data sources should be skipped")
+	assert.Equal(t, "t3.micro", instances["i-web"].Type)
+	assert.Equal(t, map[string]string{"Name": "web"}, instances["i-web"].Tags)
+	assert.NotContains(t, instances["i-web"].Unknown, pkg.AttrTags)
+	assert.Contains(t, instances["i-web"].Unknown, pkg.AttrAmi, "redacted attributes should be unknown")
+	assert.Equal(t, "t3.large", instances["i-app"].Type, "child modules should be walked")
+	assert.Equal(t, "module.app.aws_instance.app[0]", instances["i-app"].Address)
+}
+
+func TestParseData_Plan(t *testing.T) {
+	instances, err := NewTfStateParser().ParseData([]byte(planJSON))
+
+	assert.NoError(t, err)
+	assert.Len(t, instances, 1, "resources without a known ID should be skipped")
+	assert.Equal(t, "t3.small", instances["i-web"].Type, "planned values should be preferred")
+}
+
+func TestParseData_PlanPriorState(t *testing.T) {
+	instances, err := NewTfStateParser().ParseData([]byte(`{"prior_state": {"values": {"root_module": {"resources": [
+		{"mode": "managed", "type": "aws_instance", "values": {"id": "i-web", "instance_type": "t3.micro"}}
+	]}}}}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "t3.micro", instances["i-web"].Type)
+}
+
+func TestParseData_NoInstances(t *testing.T) {
+	_, err := NewTfStateParser().ParseData([]byte(`{"values": {"root_module": {}}}`))
+
+	assert.ErrorContains(t, err, "no aws_instance resources found")
+}
@@ -1,4 +1,6 @@
-// tfStateParser is a parser for Terraform .tfstate files, extracting aws_instance resources.
+// tfStateParser is a parser for Terraform state, extracting aws_instance resources.
+// Besides raw .tfstate files, it reads the JSON output of `terraform show -json`
+// for both states and saved plans.
 package tfstate
 
 import (
@@ -13,7 +15,7 @@ import (
 type tfStateParser struct{}
 
 // NewTfStateParser creates a new tfStateParser instance.
-func NewTfStateParser() pkg.Parser {
+func NewTfStateParser() pkg.DataParser {
 	return &tfStateParser{}
 }
 
@@ -23,25 +25,67 @@ func (t tfStateParser) Parse(filePath string) (map[string]pkg.Instance, error) {
 	if err != nil {
 		return nil, err
 	}
+	return t.ParseData(data)
+}
+
+// ParseData decodes Terraform state JSON and maps matching aws_instance resources by ID.
+// The format is detected from the content, so show and plan JSON can share an extension with state.
+func (t tfStateParser) ParseData(data []byte) (map[string]pkg.Instance, error) {
+	decode, err := detectFormat(data)
+	if err != nil {
+		return nil, err
+	}
+	out, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%w in state", pkg.ErrNoInstances)
+	}
+	return out, nil
+}
+
+// detectFormat picks the decoder for data by the top-level keys each format has.
+func detectFormat(data []byte) (func([]byte) (pkg.InstanceMap, error), error) {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case has(keys, "planned_values") || has(keys, "prior_state"):
+		return decodePlan, nil
+	case has(keys, "values"):
+		return decodeShow, nil
+	default:
+		return decodeState, nil
+	}
+}
+
+func has(keys map[string]json.RawMessage, key string) bool {
+	_, ok := keys[key]
+	return ok
+}
 
+// decodeState maps the aws_instance resources of a raw state file by ID.
+func decodeState(data []byte) (pkg.InstanceMap, error) {
 	var st state
 	if err := json.Unmarshal(data, &st); err != nil {
 		return nil, err
 	}
 
-	out := map[string]pkg.Instance{}
+	out := pkg.InstanceMap{}
 	for _, res := range st.Resources {
-		if res.Type != "aws_instance" {
+		if res.Type != "aws_instance" || res.Mode == "data" {
 			continue
 		}
 		for _, inst := range res.Instances {
-			out[inst.Attributes.ID] = inst.toInstance()
+			instance := inst.toInstance()
+			instance.Address = res.address(inst)
+			out[instance.ID] = instance
 		}
 	}
-
-	if len(out) == 0 {
-		return nil, fmt.Errorf("no aws_instance resources found in state")
-	}
 	return out, nil
 }
 
@@ -69,3 +69,56 @@ func TestSupportedTypes(t *testing.T) {
 	assert.Contains(t, sp.SupportedTypes(), ".tfstate")
 	assert.Contains(t, sp.SupportedTypes(), ".json")
 }
+
+func TestParseState_ExtendedAttributes(t *testing.T) {
+	instances, err := (&tfStateParser{}).Parse("../../examples/resources/terraform.tfstate")
+
+	assert.NoError(t, err)
+	inst := instances["i-0846f159803a92a1a"]
+	assert.Equal(t, "ami-0846f159803a92a1a", inst.Ami)
+	assert.Equal(t, "us-east-2", inst.AvailabilityZone)
+	assert.Equal(t, "subnet-0abcde1234567890", inst.SubnetID)
+	assert.Equal(t, "vpc-0abcde1234567890", inst.VpcID)
+	assert.Equal(t, []string{"sg-0a1b2c3d4e5f6g7h"}, inst.VpcSecurityGroupIDs)
+	assert.Equal(t, "10.0.0.10", inst.PrivateIP)
+	assert.True(t, inst.Monitoring)
+	assert.Equal(t, "x86_64", inst.Architecture)
+	assert.Equal(t, "hvm", inst.VirtualizationType)
+	assert.Equal(t, "my-iam-role", inst.IamInstanceProfile)
+}
+
+func TestToInstance_PrefersTagsAll(t *testing.T) {
+	var inst tfInstance
+	inst.Attributes.Tags = map[string]string{"Name": "web"}
+	inst.Attributes.TagsAll = map[string]string{"Name": "web", "Team": "platform"}
+
+	assert.Equal(t, inst.Attributes.TagsAll, inst.toInstance().Tags)
+
+	inst.Attributes.TagsAll = nil
+	assert.Equal(t, inst.Attributes.Tags, inst.toInstance().Tags)
+}
+
+func TestParseData_Addresses(t *testing.T) {
+	content := `{"resources": [
+		{"mode": "managed", "type": "aws_instance", "name": "single",
+		 "instances": [{"attributes": {"id": "i-single"}}]},
+		{"module": "module.web", "mode": "managed", "type": "aws_instance", "name": "app",
+		 "instances": [
+			{"index_key": "blue", "attributes": {"id": "i-blue"}},
+			{"index_key": "green", "attributes": {"id": "i-green"}}
+		 ]},
+		{"mode": "managed", "type": "aws_instance", "name": "worker",
+		 "instances": [{"index_key": 1, "attributes": {"id": "i-worker"}}]},
+		{"mode": "data", "type": "aws_instance", "name": "lookup",
+		 "instances": [{"attributes": {"id": "i-lookup"}}]}
+	]}`
+
+	instances, err := NewTfStateParser().ParseData([]byte(content))
+
+	assert.NoError(t, err)
+	assert.Len(t, instances, 4, "data sources should be skipped")
+	assert.Equal(t, "aws_instance.single", instances["i-single"].Address)
+	assert.Equal(t, `module.web.aws_instance.app["blue"]`, instances["i-blue"].Address)
+	assert.Equal(t, `module.web.aws_instance.app["green"]`, instances["i-green"].Address)
+	assert.Equal(t, "aws_instance.worker[1]", instances["i-worker"].Address)
+}
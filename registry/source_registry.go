@@ -0,0 +1,43 @@
+package registry
+
+import (
+	"net/url"
+
+	"github.com/tpriime/ec2diff/pkg"
+)
+
+// SourceRegistry maps URI schemes to remote state sources.
+type SourceRegistry struct {
+	sources map[string]pkg.StateSource
+}
+
+// NewSourceRegistry constructs a registry from a list of state sources.
+func NewSourceRegistry(sources []pkg.StateSource) *SourceRegistry {
+	reg := &SourceRegistry{sources: make(map[string]pkg.StateSource)}
+	for _, s := range sources {
+		for _, scheme := range s.SupportedSchemes() {
+			reg.sources[scheme] = s
+		}
+	}
+	return reg
+}
+
+// Get returns a source for the URI based on its scheme, if available.
+func (r *SourceRegistry) Get(uri string) (pkg.StateSource, bool) {
+	scheme, ok := Scheme(uri)
+	if !ok {
+		return nil, false
+	}
+	s, ok := r.sources[scheme]
+	return s, ok
+}
+
+// Scheme returns the scheme of a remote URI. Local paths, including
+// Windows paths with a drive letter, have none.
+func Scheme(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || len(u.Scheme) < 2 {
+		return "", false
+	}
+	return u.Scheme, true
+}
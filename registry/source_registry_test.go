@@ -0,0 +1,35 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tpriime/ec2diff/pkg"
+	"github.com/tpriime/ec2diff/pkg/mocks"
+)
+
+func TestNewSourceRegistry(t *testing.T) {
+	web := &mocks.MockStateSource{Schemes: []string{"http", "https"}}
+	s3 := &mocks.MockStateSource{Schemes: []string{"s3"}}
+	reg := NewSourceRegistry([]pkg.StateSource{web, s3})
+
+	for uri, expected := range map[string]pkg.StateSource{
+		"https://gitlab.example.com/api/v4/projects/1/terraform/state/prod": web,
+		"http://localhost:8080/state":                                       web,
+		"s3://bucket/env/terraform.tfstate":                                 s3,
+	} {
+		s, ok := reg.Get(uri)
+		assert.True(t, ok, "expected source to be found for %s", uri)
+		assert.Equal(t, expected, s)
+	}
+}
+
+func TestNewSourceRegistry_LocalPaths(t *testing.T) {
+	reg := NewSourceRegistry([]pkg.StateSource{&mocks.MockStateSource{Schemes: []string{"c", "s3"}}})
+
+	for _, path := range []string{"terraform.tfstate", "./state/terraform.tfstate", `C:\state\terraform.tfstate`, "gcs://bucket/key"} {
+		s, ok := reg.Get(path)
+		assert.False(t, ok, path)
+		assert.Nil(t, s)
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/tpriime/ec2diff/pkg"
+	"github.com/tpriime/ec2diff/pkg/logger"
+	"github.com/tpriime/ec2diff/pkg/remotestate"
+	"github.com/tpriime/ec2diff/registry"
+)
+
+// Extension of the parser used to decode remote state, and of state files found in directories
+const tfstateExt = ".tfstate"
+
+// Timeout of each request reading a remote state, so that a hung backend fails the run
+const remoteStateTimeout = time.Minute
+
+// loadStates parses every state given by cfg.FilePaths and merges them into one map.
+//
+// When more than one state is read, each instance is tagged with the state it came
+// from. Instances claimed by several states are kept from the first one, and
+// list every state that claims them in ClaimedBy. The conflict is reported with
+// the instance, so conflicts on instances out of the run's scope are only logged.
+//
+// A state without aws_instance resources is only an error when it is the single
+// file given. States found through directories, globs or several arguments are
+// commonly other stacks or empty workspaces, and are skipped.
+func loadStates(ctx context.Context, cfg *Config) (pkg.InstanceMap, error) {
+	paths, err := expandPaths(cfg.FilePaths)
+	if err != nil {
+		return nil, err
+	}
+	allowEmpty := len(cfg.FilePaths) != 1 || !isExplicitFile(cfg.FilePaths[0])
+
+	merged := pkg.InstanceMap{}
+	sources := map[string][]string{}
+	for _, path := range paths {
+		state, err := loadState(ctx, cfg, path)
+		if allowEmpty && errors.Is(err, pkg.ErrNoInstances) {
+			logger.Info(ctx, "No instances found in file, skipping", "file", path)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		logger.Info(ctx, fmt.Sprintf("Found %d instances in file", len(state)), "file", path)
+
+		for id, inst := range state {
+			if len(paths) > 1 {
+				inst.Source = path
+			}
+			if _, claimed := merged[id]; !claimed {
+				merged[id] = inst
+			}
+			sources[id] = append(sources[id], path)
+		}
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(sources)) {
+		claimedBy := sources[id]
+		if len(claimedBy) < 2 {
+			continue
+		}
+		logger.Warn(ctx, "Instance claimed by multiple states", "instanceID", id, "files", claimedBy)
+		inst := merged[id]
+		inst.ClaimedBy = claimedBy
+		merged[id] = inst
+	}
+	return merged, nil
+}
+
+// expandPaths resolves the -file arguments into the states to read.
+//
+// Remote URIs are kept as is. Globs are expanded, and directories are searched
+// recursively for .tfstate files, which includes the workspaces kept under
+// terraform.tfstate.d. Each state is read once, even if several arguments match it
+// by different paths, e.g. ./states/a.tfstate and states/*.tfstate.
+func expandPaths(args []string) ([]string, error) {
+	var paths []string
+	seen := map[string]struct{}{}
+	add := func(path string) {
+		key := path
+		if _, remote := registry.Scheme(path); !remote {
+			path = filepath.Clean(path)
+			if abs, err := filepath.Abs(path); err == nil {
+				key = abs
+			}
+		}
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			paths = append(paths, path)
+		}
+	}
+
+	for _, arg := range args {
+		if _, remote := registry.Scheme(arg); remote {
+			add(arg)
+			continue
+		}
+
+		matches := []string{arg}
+		if strings.ContainsAny(arg, "*?[") {
+			var err error
+			if matches, err = filepath.Glob(arg); err != nil {
+				return nil, fmt.Errorf("invalid glob '%s': %w", arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match '%s'", arg)
+			}
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil || !info.IsDir() {
+				add(match) // missing files are reported by the parser
+				continue
+			}
+			files, err := findStates(match)
+			if err != nil {
+				return nil, err
+			}
+			if len(files) == 0 {
+				return nil, fmt.Errorf("no %s files found in '%s'", tfstateExt, match)
+			}
+			for _, f := range files {
+				add(f)
+			}
+		}
+	}
+	return paths, nil
+}
+
+// isExplicitFile reports whether arg names a single state, rather than a glob or directory to search.
+func isExplicitFile(arg string) bool {
+	if _, remote := registry.Scheme(arg); remote {
+		return true
+	}
+	if strings.ContainsAny(arg, "*?[") {
+		return false
+	}
+	info, err := os.Stat(arg)
+	return err != nil || !info.IsDir()
+}
+
+// findStates walks dir for .tfstate files, skipping the .terraform working directory.
+func findStates(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".terraform" {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && filepath.Ext(path) == tfstateExt {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// loadState parses the state at path. Remote URIs are read through the
+// source registered for their scheme and decoded as Terraform state.
+func loadState(ctx context.Context, cfg *Config, path string) (pkg.InstanceMap, error) {
+	if cfg.Sources != nil {
+		if source, ok := cfg.Sources.Get(path); ok {
+			parser, ok := cfg.Registry.Get(tfstateExt)
+			dataParser, isData := parser.(pkg.DataParser)
+			if !ok || !isData {
+				return nil, errors.New("no parser found for remote state")
+			}
+
+			logger.Info(ctx, "Reading remote state", "uri", path)
+			data, err := source.Read(ctx, path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read remote state: %w", err)
+			}
+			state, err := dataParser.ParseData(data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse remote state: %w", err)
+			}
+			return state, nil
+		}
+	}
+
+	if scheme, ok := registry.Scheme(path); ok {
+		return nil, fmt.Errorf("unsupported state backend %s://", scheme)
+	}
+
+	parser, ok := cfg.Registry.Get(path)
+	if !ok {
+		return nil, fmt.Errorf("no parser found for file extension %s", filepath.Ext(path))
+	}
+	state, err := parser.Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse file: %w", err)
+	}
+	return state, nil
+}
+
+// newSourceRegistry registers the remote state backends, configured from the
+// same environment variables Terraform reads for them. The AWS config is only
+// loaded once an S3 state is read.
+func newSourceRegistry() *registry.SourceRegistry {
+	client := &http.Client{Timeout: remoteStateTimeout}
+
+	tfcAddress := os.Getenv("TFE_ADDRESS")
+	if tfcAddress == "" {
+		tfcAddress = remotestate.DefaultTFCAddress
+	}
+
+	return registry.NewSourceRegistry([]pkg.StateSource{
+		remotestate.NewS3Source(func(o *s3.Options) { o.HTTPClient = client }),
+		remotestate.NewTFCSource(client, tfcAddress, remotestate.TFCTokenFromEnv(tfcAddress)),
+		remotestate.NewHTTPSource(client, os.Getenv("TF_HTTP_USERNAME"), os.Getenv("TF_HTTP_PASSWORD")),
+	})
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tpriime/ec2diff/pkg"
+	"github.com/tpriime/ec2diff/pkg/drift"
+	"github.com/tpriime/ec2diff/pkg/mocks"
+	"github.com/tpriime/ec2diff/pkg/tfstate"
+	"github.com/tpriime/ec2diff/registry"
+)
+
+// writeState writes a state holding one aws_instance per ID to path.
+func writeState(t *testing.T, path string, ids ...string) {
+	t.Helper()
+	instances := ""
+	for i, id := range ids {
+		if i > 0 {
+			instances += ","
+		}
+		instances += `{"attributes":{"id":"` + id + `"}}`
+	}
+	content := `{"resources":[{"mode":"managed","type":"aws_instance","name":"app","instances":[` + instances + `]}]}`
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExpandPaths(t *testing.T) {
+	dir := t.TempDir()
+	writeState(t, filepath.Join(dir, "stack", "terraform.tfstate"), "i-1")
+	writeState(t, filepath.Join(dir, "stack", "terraform.tfstate.d", "prod", "terraform.tfstate"), "i-2")
+	writeState(t, filepath.Join(dir, "stack", ".terraform", "terraform.tfstate"), "i-3")
+	writeState(t, filepath.Join(dir, "web.tfstate"), "i-4")
+
+	paths, err := expandPaths([]string{
+		filepath.Join(dir, "stack"),
+		filepath.Join(dir, "*.tfstate"),
+		filepath.Join(dir, "web.tfstate"),
+		"s3://bucket/terraform.tfstate",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "stack", "terraform.tfstate"),
+		filepath.Join(dir, "stack", "terraform.tfstate.d", "prod", "terraform.tfstate"),
+		filepath.Join(dir, "web.tfstate"),
+		"s3://bucket/terraform.tfstate",
+	}, paths, "workspaces should be found and duplicates read once")
+}
+
+func TestExpandPaths_SameFileByDifferentPaths(t *testing.T) {
+	dir := t.TempDir()
+	writeState(t, filepath.Join(dir, "st", "a.tfstate"), "i-1")
+	t.Chdir(dir)
+
+	paths, err := expandPaths([]string{"./st/a.tfstate", "st/*.tfstate", filepath.Join(dir, "st", "a.tfstate")})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join("st", "a.tfstate")}, paths)
+}
+
+func TestExpandPaths_NoMatches(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := expandPaths([]string{filepath.Join(dir, "*.tfstate")})
+	assert.ErrorContains(t, err, "no files match")
+
+	_, err = expandPaths([]string{dir})
+	assert.ErrorContains(t, err, "no .tfstate files found")
+}
+
+func TestLoadStates_MergesWithProvenance(t *testing.T) {
+	dir := t.TempDir()
+	web := filepath.Join(dir, "web.tfstate")
+	db := filepath.Join(dir, "db.tfstate")
+	writeState(t, web, "i-web", "i-shared")
+	writeState(t, db, "i-db", "i-shared")
+
+	cfg := &Config{
+		FilePaths: []string{web, db},
+		Registry:  registry.NewParserRegistry([]pkg.Parser{tfstate.NewTfStateParser()}),
+	}
+
+	state, err := loadStates(t.Context(), cfg)
+
+	assert.NoError(t, err)
+	assert.Len(t, state, 3)
+	assert.Equal(t, web, state["i-web"].Source)
+	assert.Equal(t, db, state["i-db"].Source)
+	assert.Equal(t, web, state["i-shared"].Source, "the first state claiming an instance should be kept")
+
+	assert.Equal(t, []string{web, db}, state["i-shared"].ClaimedBy)
+	assert.Empty(t, state["i-web"].ClaimedBy)
+}
+
+func TestLoadStates_SingleFileHasNoSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "terraform.tfstate")
+	writeState(t, path, "i-1")
+
+	cfg := &Config{
+		FilePaths: []string{path},
+		Registry:  registry.NewParserRegistry([]pkg.Parser{tfstate.NewTfStateParser()}),
+	}
+
+	state, err := loadStates(t.Context(), cfg)
+
+	assert.NoError(t, err)
+	assert.Empty(t, state["i-1"].ClaimedBy)
+	assert.Empty(t, state["i-1"].Source)
+}
+
+func TestLoadStates_SkipsStatesWithoutInstances(t *testing.T) {
+	dir := t.TempDir()
+	writeState(t, filepath.Join(dir, "app", "terraform.tfstate"), "i-1")
+	network := filepath.Join(dir, "network", "terraform.tfstate")
+	content := `{"resources":[{"mode":"managed","type":"aws_s3_bucket","name":"logs","instances":[{"attributes":{"id":"logs"}}]}]}`
+	if err := os.MkdirAll(filepath.Dir(network), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(network, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	reg := registry.NewParserRegistry([]pkg.Parser{tfstate.NewTfStateParser()})
+
+	state, err := loadStates(t.Context(), &Config{FilePaths: []string{dir}, Registry: reg})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"i-1"}, slices.Collect(maps.Keys(state)))
+
+	_, err = loadStates(t.Context(), &Config{FilePaths: []string{network}, Registry: reg})
+	assert.ErrorIs(t, err, pkg.ErrNoInstances, "a single explicit file should still require instances")
+}
+
+func TestExecute_ReportsConflicts(t *testing.T) {
+	dir := t.TempDir()
+	writeState(t, filepath.Join(dir, "web.tfstate"), "i-shared")
+	writeState(t, filepath.Join(dir, "db.tfstate"), "i-shared")
+
+	printer := &mocks.MockReportPrinter{}
+	cfg := &Config{
+		FilePaths:        []string{filepath.Join(dir, "*.tfstate")},
+		Attributes:       []string{pkg.AttrInstanceState},
+		Registry:         registry.NewParserRegistry([]pkg.Parser{tfstate.NewTfStateParser()}),
+		Fetcher:          &mocks.MockLiveFetcher{Instances: pkg.InstanceMap{"i-shared": pkg.Instance{ID: "i-shared"}}},
+		Checker:          drift.NewDriftChecker(1),
+		ReportPrinter:    printer,
+		HelpFn:           func() {},
+		DetailedExitCode: true,
+	}
+
+	err := execute(t.Context(), cfg)
+
+	var driftErr *driftExitError
+	assert.ErrorAs(t, err, &driftErr)
+	assert.Equal(t, exitDrift, driftErr.code)
+	assert.Len(t, printer.Output, 1, "the conflict should be reported on the instance's own report")
+	assert.Equal(t, pkg.CommentNoDriftDetected, printer.Output[0].Comment)
+	assert.True(t, printer.Output[0].Conflict)
+	assert.Equal(t, []string{filepath.Join(dir, "db.tfstate"), filepath.Join(dir, "web.tfstate")}, printer.Output[0].Sources)
+	assert.Equal(t, pkg.Summary{Total: 1, NoDrift: 1, Conflicts: 1}, printer.Summary)
+}
+
+func TestExecute_ScopesConflictsToInstanceIDs(t *testing.T) {
+	dir := t.TempDir()
+	writeState(t, filepath.Join(dir, "web.tfstate"), "i-web", "i-shared")
+	writeState(t, filepath.Join(dir, "db.tfstate"), "i-shared")
+
+	printer := &mocks.MockReportPrinter{}
+	cfg := &Config{
+		FilePaths:        []string{filepath.Join(dir, "*.tfstate")},
+		InstanceIDs:      []string{"i-web"},
+		Attributes:       []string{pkg.AttrInstanceState},
+		Registry:         registry.NewParserRegistry([]pkg.Parser{tfstate.NewTfStateParser()}),
+		Fetcher:          &mocks.MockLiveFetcher{Instances: pkg.InstanceMap{"i-web": pkg.Instance{ID: "i-web"}}},
+		Checker:          drift.NewDriftChecker(1),
+		ReportPrinter:    printer,
+		HelpFn:           func() {},
+		DetailedExitCode: true,
+	}
+
+	err := execute(t.Context(), cfg)
+
+	assert.NoError(t, err)
+	assert.Len(t, printer.Output, 1)
+	assert.Equal(t, "i-web", printer.Output[0].InstanceID)
+	assert.Zero(t, printer.Summary.Conflicts)
+}
+
+func TestExecute_SkipsConflictsOutOfScope(t *testing.T) {
+	dir := t.TempDir()
+	writeState(t, filepath.Join(dir, "web.tfstate"), "i-shared")
+	writeState(t, filepath.Join(dir, "db.tfstate"), "i-shared")
+
+	printer := &mocks.MockReportPrinter{}
+	cfg := &Config{
+		FilePaths:        []string{filepath.Join(dir, "*.tfstate")},
+		Scope:            scopeLive,
+		Attributes:       []string{pkg.AttrInstanceState},
+		Registry:         registry.NewParserRegistry([]pkg.Parser{tfstate.NewTfStateParser()}),
+		Fetcher:          &mocks.MockLiveFetcher{Instances: pkg.InstanceMap{}},
+		Checker:          drift.NewDriftChecker(1),
+		ReportPrinter:    printer,
+		HelpFn:           func() {},
+		DetailedExitCode: true,
+	}
+
+	err := execute(t.Context(), cfg)
+
+	assert.NoError(t, err, "a conflict on an instance the live scope leaves out should not count as drift")
+	assert.Empty(t, printer.Output)
+	assert.Zero(t, printer.Summary.Conflicts)
+}